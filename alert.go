@@ -0,0 +1,135 @@
+package commonmark
+
+import (
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Alerts is an Extension that adds support for alerts, as on GitHub: a block
+// quote whose first line is a marker like [!NOTE] becomes an Alert.
+//
+//	> [!WARNING]
+//	> Do not restart the database during business hours.
+//
+// The kinds of alerts that GitHub supports are recognized: NOTE, TIP,
+// IMPORTANT, WARNING and CAUTION. The marker is case-insensitive, and must be
+// on a line by itself. Block quotes with any other marker are left alone.
+var Alerts Extension = &alertsExtension{defaultAlertKinds}
+
+// AlertsWithKinds returns an Extension like Alerts, which recognizes the
+// given kinds of alerts in addition to the default ones. A kind with the same
+// name as a default kind replaces it.
+func AlertsWithKinds(kinds ...AlertKind) Extension {
+	return &alertsExtension{append(append([]AlertKind(nil), defaultAlertKinds...), kinds...)}
+}
+
+// AlertKind is a kind of alert.
+type AlertKind struct {
+	// Name is the name in the marker, like NOTE for [!NOTE].
+	Name string
+	// Title is the title that is shown at the top of the alert, like Note.
+	Title string
+}
+
+var defaultAlertKinds = []AlertKind{
+	{"NOTE", "Note"},
+	{"TIP", "Tip"},
+	{"IMPORTANT", "Important"},
+	{"WARNING", "Warning"},
+	{"CAUTION", "Caution"},
+}
+
+// alertsExtension is used through a pointer, because it is not comparable.
+type alertsExtension struct {
+	kinds []AlertKind
+}
+
+func (e *alertsExtension) Extend(c *Converter) {
+	titles := make(map[string]string)
+	for _, kind := range e.kinds {
+		titles[strings.ToLower(kind.Name)] = kind.Title
+	}
+	c.AddTransform(AfterBlockParsing, func(doc *Document) error {
+		findAlerts(doc, titles)
+		return nil
+	}, 0)
+	c.Renderer().SetBlockFunc(&Alert{}, renderAlert)
+}
+
+// Alert is a block quote that has been marked as an alert, which can contain
+// any blocks.
+type Alert struct {
+	BaseBlock
+	// Kind is the name of the kind of alert in lower case, like note.
+	Kind string
+	// Title is the title of the kind of alert, like Note.
+	Title string
+}
+
+func (a *Alert) CanContain(Block) bool {
+	return true
+}
+
+var alertMarkerRe = regexp.MustCompile(`^\[!([A-Za-z]+)\] *(?:\n|$)`)
+
+// findAlerts replaces the block quotes that start with the marker of one of
+// the kinds of alerts, which map the lower case names of the kinds to their
+// titles, by Alerts.
+func findAlerts(doc *Document, titles map[string]string) {
+	walkBlocks(doc, func(b Block) {
+		children := b.Children()
+		for i, child := range children {
+			if quote, ok := child.(*BlockQuote); ok {
+				if alert := alertFromBlockQuote(quote, titles); alert != nil {
+					children[i] = alert
+				}
+			}
+		}
+	})
+}
+
+// alertFromBlockQuote returns the Alert for the block quote, with the marker
+// removed, or nil if it does not start with a known marker.
+func alertFromBlockQuote(quote *BlockQuote, titles map[string]string) *Alert {
+	children := quote.Children()
+	if len(children) == 0 {
+		return nil
+	}
+	par, ok := children[0].(*Paragraph)
+	if !ok {
+		return nil
+	}
+	m := alertMarkerRe.FindSubmatch(par.Content)
+	if m == nil {
+		return nil
+	}
+	kind := strings.ToLower(string(m[1]))
+	title, ok := titles[kind]
+	if !ok {
+		return nil
+	}
+
+	alert := &Alert{Kind: kind, Title: title}
+	if len(par.Content) > len(m[0]) {
+		par.Content = par.Content[len(m[0]):]
+		alert.AppendChild(par)
+	}
+	for _, child := range children[1:] {
+		alert.AppendChild(child)
+	}
+	return alert
+}
+
+func renderAlert(r *HTMLRenderer, b Block, out io.Writer) {
+	alert := b.(*Alert)
+	io.WriteString(out, "<div")
+	r.WriteAttr("class", []byte("markdown-alert markdown-alert-"+alert.Kind), out)
+	io.WriteString(out, ">\n<p")
+	r.WriteAttr("class", []byte("markdown-alert-title"), out)
+	io.WriteString(out, ">")
+	WriteEscaped([]byte(alert.Title), out)
+	io.WriteString(out, "</p>\n")
+	r.RenderChildren(alert, out)
+	io.WriteString(out, "</div>\n")
+}
@@ -0,0 +1,25 @@
+package commonmark
+
+import (
+	"testing"
+)
+
+func TestAlerts(t *testing.T) {
+	runHTMLTests(t, New(WithExtensions(Alerts)), []htmlTest{
+		{"> [!NOTE]\n> Useful `information`.\n", "<div class=\"markdown-alert markdown-alert-note\">\n<p class=\"markdown-alert-title\">Note</p>\n<p>Useful <code>information</code>.</p>\n</div>\n"},
+		{"> [!warning]  \n> Careful.\n>\n> - a\n", "<div class=\"markdown-alert markdown-alert-warning\">\n<p class=\"markdown-alert-title\">Warning</p>\n<p>Careful.</p>\n<ul>\n<li>a</li>\n</ul>\n</div>\n"},
+		{"> [!TIP]\n>\n> Text\n", "<div class=\"markdown-alert markdown-alert-tip\">\n<p class=\"markdown-alert-title\">Tip</p>\n<p>Text</p>\n</div>\n"},
+		{"> [!CAUTION]\nlazy\n", "<div class=\"markdown-alert markdown-alert-caution\">\n<p class=\"markdown-alert-title\">Caution</p>\n<p>lazy</p>\n</div>\n"},
+		{"> [!NOTE] Text\n", "<blockquote>\n<p>[!NOTE] Text</p>\n</blockquote>\n"},
+		{"> [!FOO]\n> Text\n", "<blockquote>\n<p>[!FOO]\nText</p>\n</blockquote>\n"},
+		{"> Text\n> [!NOTE]\n", "<blockquote>\n<p>Text\n[!NOTE]</p>\n</blockquote>\n"},
+		{"- > [!IMPORTANT]\n  > Text\n", "<ul>\n<li><div class=\"markdown-alert markdown-alert-important\">\n<p class=\"markdown-alert-title\">Important</p>\n<p>Text</p>\n</div></li>\n</ul>\n"},
+	})
+	runHTMLTests(t, New(WithExtensions(AlertsWithKinds(AlertKind{"DANGER", "Danger!"}, AlertKind{"NOTE", "Remark"}))), []htmlTest{
+		{"> [!Danger]\n> Text\n", "<div class=\"markdown-alert markdown-alert-danger\">\n<p class=\"markdown-alert-title\">Danger!</p>\n<p>Text</p>\n</div>\n"},
+		{"> [!NOTE]\n> Text\n", "<div class=\"markdown-alert markdown-alert-note\">\n<p class=\"markdown-alert-title\">Remark</p>\n<p>Text</p>\n</div>\n"},
+	})
+	runHTMLTests(t, New(), []htmlTest{
+		{"> [!NOTE]\n> Text\n", "<blockquote>\n<p>[!NOTE]\nText</p>\n</blockquote>\n"},
+	})
+}
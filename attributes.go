@@ -0,0 +1,224 @@
+package commonmark
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Attributes is an Extension that lets the input give HTML attributes to
+// headers, fenced code blocks, paragraphs, links and images, using an
+// attribute block like in Pandoc or kramdown:
+//
+//	# Header {#custom-id .class1 .class2 key=value}
+//
+//	```go {.numberLines startFrom="10"}
+//	...
+//	```
+//
+//	A paragraph.
+//	{.lead}
+//
+//	[link](/url){target=_blank} and ![image](/src.png){width=100}
+//
+// An attribute block consists of one or more items between braces,
+// separated by spaces: #id sets the id, .class adds a class, and key=value
+// sets any other attribute, where the value may be in single or double
+// quotes. The block must come at the end of the content of a header (with a
+// space before it), at the end of the info string of a fenced code block, on
+// the last line of a paragraph by itself, or directly after a link or image.
+//
+// The attributes are stored in the Attributes fields of the blocks and
+// inlines, except for the id of a header, which is stored in its ID field, so
+// that WithHeaderIDs and BuildTOC respect it.
+//
+// Because the attributes come from the input, the renderer filters them like
+// the attributes of raw HTML tags: if it has an HTMLPolicy, only the
+// attributes that the policy allows on the element are written. Without a
+// policy, event handler attributes (on*), style, and attributes that hold
+// URLs, like href and src, are left out; but that is no more than a
+// blocklist, so attribute blocks in untrusted input need a policy. Attributes
+// that the renderer writes itself, like the href of a link, cannot be
+// overridden.
+var Attributes Extension = attributesExtension{}
+
+type attributesExtension struct{}
+
+func (attributesExtension) Extend(c *Converter) {
+	c.parseOptions.attributes = true
+	c.AddTransform(AfterBlockParsing, parseBlockAttributes, 0)
+}
+
+// Attribute is an HTML attribute that was given in the input; see
+// Attributes.
+type Attribute struct {
+	Key, Value string
+}
+
+const (
+	attributeName = `[^\s{}#.="']+`
+	attributeItem = `(?:#` + attributeName + `|\.` + attributeName + `|` + htmlAttributeName + `=(?:"[^"]*"|'[^']*'|` + attributeName + `))`
+)
+
+var (
+	attributeBlockRe = regexp.MustCompile(`^\{ *(` + attributeItem + `(?: +` + attributeItem + `)*) *\}`)
+	attributeItemRe  = regexp.MustCompile(attributeItem)
+)
+
+// parseAttributeBlock parses the attribute block at the start of data. It
+// returns the attributes and the length of the block, or nil and 0 if data
+// does not start with an attribute block.
+//
+// The attributes are normalized: the id, if any, comes first, followed by
+// all classes combined into a single class attribute, and then the other
+// attributes in order. If an attribute is given more than once, the last
+// value is used.
+func parseAttributeBlock(data []byte) ([]Attribute, int) {
+	m := attributeBlockRe.FindSubmatch(data)
+	if m == nil {
+		return nil, 0
+	}
+	var id string
+	var classes []string
+	var others []Attribute
+	for _, item := range attributeItemRe.FindAll(m[1], -1) {
+		switch item[0] {
+		case '#':
+			id = string(item[1:])
+		case '.':
+			classes = append(classes, string(item[1:]))
+		default:
+			eq := bytes.IndexByte(item, '=')
+			key, value := string(item[:eq]), item[eq+1:]
+			if value[0] == '"' || value[0] == '\'' {
+				value = value[1 : len(value)-1]
+			}
+			switch strings.ToLower(key) {
+			case "id":
+				id = string(value)
+			case "class":
+				classes = append(classes, string(value))
+			default:
+				others = setAttribute(others, key, string(value))
+			}
+		}
+	}
+
+	var attrs []Attribute
+	if id != "" {
+		attrs = append(attrs, Attribute{"id", id})
+	}
+	if len(classes) > 0 {
+		attrs = append(attrs, Attribute{"class", strings.Join(classes, " ")})
+	}
+	return append(attrs, others...), len(m[0])
+}
+
+// setAttribute sets the value of the attribute with the given key, which is
+// matched case-insensitively, or appends it if there is no such attribute
+// yet.
+func setAttribute(attrs []Attribute, key, value string) []Attribute {
+	for i := range attrs {
+		if strings.EqualFold(attrs[i].Key, key) {
+			attrs[i].Value = value
+			return attrs
+		}
+	}
+	return append(attrs, Attribute{key, value})
+}
+
+// trailingAttributes looks for an attribute block at the end of data, after
+// a space. It returns the attributes and the data before the block, with
+// trailing spaces removed. If there is no attribute block, it returns nil and
+// data.
+func trailingAttributes(data []byte) ([]Attribute, []byte) {
+	trimmed := bytes.TrimRight(data, " \n")
+	if len(trimmed) == 0 || trimmed[len(trimmed)-1] != '}' {
+		return nil, data
+	}
+	for start := bytes.LastIndexByte(trimmed, '{'); start >= 0; start = bytes.LastIndexByte(trimmed[:start], '{') {
+		if start > 0 && trimmed[start-1] != ' ' {
+			continue
+		}
+		if attrs, length := parseAttributeBlock(trimmed[start:]); start+length == len(trimmed) {
+			return attrs, bytes.TrimRight(trimmed[:start], " ")
+		}
+	}
+	return nil, data
+}
+
+// parseBlockAttributes is a Transform that moves the attribute blocks from the
+// content of headers, fenced code blocks and paragraphs to their Attributes.
+func parseBlockAttributes(doc *Document) error {
+	walkBlocks(doc, func(b Block) {
+		switch t := b.(type) {
+		case *Header:
+			attrs, content := trailingAttributes(t.Content)
+			if attrs == nil {
+				return
+			}
+			if attrs[0].Key == "id" {
+				t.ID = attrs[0].Value
+				attrs = attrs[1:]
+			}
+			t.Content = content
+			t.Attributes = attrs
+		case *FencedCodeBlock:
+			t.Attributes, t.Info = trailingAttributes(t.Info)
+		case *Paragraph:
+			t.Attributes, t.Content = paragraphAttributes(t.Content)
+		}
+	})
+	return nil
+}
+
+// paragraphAttributes looks for an attribute block on the last line of the
+// content of a paragraph, which must contain nothing else. It returns the
+// attributes and the content without that line. If there is no such block,
+// or if it is on the only line, it returns nil and the content.
+func paragraphAttributes(content []byte) ([]Attribute, []byte) {
+	trimmed := bytes.TrimRight(content, " \n")
+	newline := bytes.LastIndexByte(trimmed, '\n')
+	if newline < 0 {
+		return nil, content
+	}
+	attrs, length := parseAttributeBlock(trimmed[newline+1:])
+	if newline+1+length != len(trimmed) {
+		return nil, content
+	}
+	return attrs, trimmed[:newline+1]
+}
+
+// WriteAttributes writes the attributes of an element with the given tag
+// name, each with a leading space, as WriteAttr does. Attributes that the
+// renderer's HTMLPolicy does not allow on the tag are left out; without a
+// policy, event handlers (on*), style and URL attributes are left out.
+//
+// written holds the names of the attributes that the caller has already
+// written on the element. Attributes with those names are left out, as are
+// all but the first of attributes with the same name, so that the element
+// does not get duplicate attributes.
+func (r *HTMLRenderer) WriteAttributes(tag string, attrs []Attribute, written []string, out io.Writer) {
+	seen := make(map[string]bool)
+	for _, name := range written {
+		seen[strings.ToLower(name)] = true
+	}
+	for _, attr := range attrs {
+		key := strings.ToLower(attr.Key)
+		if !seen[key] && r.allowsAttribute(tag, attr.Key, attr.Value) {
+			r.WriteAttr(attr.Key, []byte(attr.Value), out)
+		}
+		seen[key] = true
+	}
+}
+
+// allowsAttribute returns whether an attribute from the input may be written
+// on an element with the given tag name.
+func (r *HTMLRenderer) allowsAttribute(tag, attr, value string) bool {
+	if r.policy != nil {
+		return r.policy.allowsAttr(tag, attr, []byte(value))
+	}
+	attr = strings.ToLower(attr)
+	return !strings.HasPrefix(attr, "on") && attr != "style" && !urlAttrs[attr]
+}
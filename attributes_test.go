@@ -0,0 +1,58 @@
+package commonmark
+
+import (
+	"testing"
+)
+
+func TestAttributes(t *testing.T) {
+	runHTMLTests(t, New(WithExtensions(Attributes)), []htmlTest{
+		{"# Intro {#start .big}\n", "<h1 id=\"start\" class=\"big\">Intro</h1>\n"},
+		{"Intro {.a .b data-x=\"1 2\" #i}\n===\n", "<h1 id=\"i\" class=\"a b\" data-x=\"1 2\">Intro</h1>\n"},
+		{"## Intro {#a} ##\n", "<h2 id=\"a\">Intro</h2>\n"},
+		{"# Intro{#a}\n", "<h1>Intro{#a}</h1>\n"},
+		{"# Intro {}\n", "<h1>Intro {}</h1>\n"},
+		{"# {#a}\n", "<h1 id=\"a\"></h1>\n"},
+		{"```go {#main .numberLines startFrom=10}\nx\n```\n", "<pre id=\"main\" class=\"numberLines\" startFrom=\"10\"><code class=\"language-go\">x\n</code></pre>\n"},
+		{"``` {.c}\nx\n```\n", "<pre class=\"c\"><code>x\n</code></pre>\n"},
+		{"Some text.\n{.lead title='A & B'}\n", "<p class=\"lead\" title=\"A &amp; B\">Some text.</p>\n"},
+		{"{.lead}\n", "<p>{.lead}</p>\n"},
+		{"Some {.lead} text.\n", "<p>Some {.lead} text.</p>\n"},
+		{"[a](/u){target=_blank .ext} b\n", "<p><a href=\"/u\" class=\"ext\" target=\"_blank\">a</a> b</p>\n"},
+		{"![a](/i.png \"t\"){width=100 width=200}\n", "<p><img src=\"/i.png\" alt=\"a\" title=\"t\" width=\"200\" /></p>\n"},
+		{"[a](/u) {.x}\n", "<p><a href=\"/u\">a</a> {.x}</p>\n"},
+		{"[a]{.x}\n", "<p>[a]{.x}</p>\n"},
+	})
+	// Attributes are filtered like those of raw HTML.
+	runHTMLTests(t, New(WithExtensions(Attributes)), []htmlTest{
+		{"hello\n{onclick=alert(1) style='x' title=t}\n", "<p title=\"t\">hello</p>\n"},
+		{"# h {onclick=alert(1) ONMOUSEOVER=x}\n", "<h1>h</h1>\n"},
+		{"[x](javascript:alert(1)){onmouseover=alert(1)}\n", "<p><a href=\"javascript:alert(1)\">x</a></p>\n"},
+		{"a\n{formaction=x HREF=y src=z}\n", "<p>a</p>\n"},
+	})
+	// Attribute blocks cannot add attributes that the renderer writes itself,
+	// or repeat attributes.
+	runHTMLTests(t, New(WithExtensions(Attributes)), []htmlTest{
+		{"[a](/u){href=\"javascript:alert(1)\"}\n", "<p><a href=\"/u\">a</a></p>\n"},
+		{"![a](/u){src=x alt=y}\n", "<p><img src=\"/u\" alt=\"a\" /></p>\n"},
+		{"[a](/u \"t\"){title=x} [b](/v){title=y}\n", "<p><a href=\"/u\" title=\"t\">a</a> <a href=\"/v\" title=\"y\">b</a></p>\n"},
+		{"# h {#a ID=b title=x TITLE=y}\n", "<h1 id=\"b\" title=\"y\">h</h1>\n"},
+	})
+	policy := NewHTMLPolicy()
+	policy.AllowAttrs("a", "target", "href")
+	policy.AllowAttrs("p", "cite")
+	policy.AllowGlobalAttrs("class")
+	runHTMLTests(t, New(WithExtensions(Attributes), WithHTMLPolicy(policy)), []htmlTest{
+		{"hello\n{.lead onclick=alert(1) title=t}\n", "<p class=\"lead\">hello</p>\n"},
+		{"# h {#id .c onclick=alert(1) target=x}\n", "<h1 id=\"id\" class=\"c\">h</h1>\n"},
+		{"[x](/u){target=_blank onmouseover=alert(1)}\n", "<p><a href=\"/u\" target=\"_blank\">x</a></p>\n"},
+		{"[x](/u){href=/v}\n", "<p><a href=\"/u\">x</a></p>\n"},
+		{"a\n{cite=/source}\n\nb\n{cite=\"javascript:alert(1)\"}\n", "<p cite=\"/source\">a</p>\n<p>b</p>\n"},
+	})
+	runHTMLTests(t, New(), []htmlTest{
+		{"# Intro {#start}\n", "<h1>Intro {#start}</h1>\n"},
+		{"[a](/u){.x}\n", "<p><a href=\"/u\">a</a>{.x}</p>\n"},
+	})
+	runHTMLTests(t, New(WithExtensions(Attributes), WithHeaderIDs(nil), WithTOCMarker(1, 6)), []htmlTest{
+		{"[TOC]\n\n# One {#first}\n# Two\n", "<ul>\n<li><a href=\"#first\">One</a></li>\n<li><a href=\"#two\">Two</a></li>\n</ul>\n<h1 id=\"first\">One</h1>\n<h1 id=\"two\">Two</h1>\n"},
+	})
+}
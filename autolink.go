@@ -0,0 +1,194 @@
+package commonmark
+
+import (
+	"bytes"
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ExtendedAutolinks is an Extension that turns bare URLs and email addresses
+// into links, as specified by GitHub Flavored Markdown:
+// https://github.github.com/gfm/#autolinks-extension-
+//
+// "Autolinks can also be constructed without requiring the use of < and to >
+// to delimit them, although they will be recognized under a smaller set of
+// circumstances. All such recognized autolinks can only come at the beginning
+// of a line, after whitespace, or any of the delimiting characters *, _, ~,
+// and (."
+//
+// Links start with www., http://, https:// or ftp://, or are email addresses
+// like foo@bar.baz. They are not recognized inside the text of other links.
+var ExtendedAutolinks Extension = extendedAutolinksExtension{}
+
+type extendedAutolinksExtension struct{}
+
+func (extendedAutolinksExtension) Extend(c *Converter) {
+	// URLs are triggered by the first letters of their prefixes, which are
+	// matched case-insensitively, like on GitHub. Email
+	// addresses are triggered by the '@', and claim the local part before
+	// it by backtracking.
+	for _, trigger := range []byte("whfWHF") {
+		c.AddInlineParser(trigger, extendedAutolinkParser{}, 0)
+	}
+	c.AddInlineParser('@', extendedEmailAutolinkParser{}, 0)
+}
+
+// "A valid domain consists of segments of alphanumeric characters,
+// underscores (_) and hyphens (-) separated by periods (.)."
+const autolinkDomain = `[a-zA-Z0-9_-]+(?:\.[a-zA-Z0-9_-]+)+`
+
+var (
+	// "An extended url autolink will be recognised when one of the schemes
+	// http://, https://, or ftp://, followed by a valid domain, then zero or
+	// more non-space non-< characters". An extended www autolink is the
+	// same, with www. instead of the scheme. The prefixes are matched
+	// case-insensitively.
+	autolinkURLRe = regexp.MustCompile(`^(?i:(?:https?|ftp)://|www\.)(` + autolinkDomain + `|[a-zA-Z0-9_-]+)[^\s<]*`)
+	// "An extended email autolink will be recognised when an email address
+	// is recognised within any text node." autolinkEmailDomainRe matches
+	// the part from the '@'.
+	autolinkEmailDomainRe = regexp.MustCompile(`^@` + autolinkDomain)
+	// autolinkEntityRe matches something that looks like an entity reference
+	// at the end of a link.
+	autolinkEntityRe = regexp.MustCompile(`&[a-zA-Z0-9]+;$`)
+)
+
+// canStartAutolink returns whether an extended autolink can start at the
+// given position, which must be "at the beginning of a line, after
+// whitespace, or any of the delimiting characters *, _, ~, and (".
+func canStartAutolink(data []byte, pos int) bool {
+	if pos == 0 {
+		return true
+	}
+	before, _ := utf8.DecodeLastRune(data[:pos])
+	return unicode.IsSpace(before) || before == '*' || before == '_' || before == '~' || before == '('
+}
+
+// extendedAutolinkParser parses URL autolinks.
+type extendedAutolinkParser struct{}
+
+func (extendedAutolinkParser) Parse(s InlineParserState) (Inline, int) {
+	data, pos := s.Data(), s.Pos()
+	if s.InLinkText() || !canStartAutolink(data, pos) {
+		return nil, 0
+	}
+	data = data[pos:]
+
+	if m := autolinkURLRe.FindSubmatchIndex(data); m != nil {
+		text := trimAutolink(data[:m[1]])
+		if len(text) <= m[2] {
+			return nil, 0
+		}
+		// Trailing characters of the domain itself may have been trimmed.
+		domainEnd := m[3]
+		if len(text) < domainEnd {
+			domainEnd = len(text)
+		}
+		www := bytes.EqualFold(text[:4], []byte("www."))
+		if !isValidAutolinkDomain(text[m[2]:domainEnd], www) {
+			return nil, 0
+		}
+		destination := text
+		if www {
+			// "The scheme http will be inserted automatically".
+			destination = append([]byte("http://"), text...)
+		}
+		return &Link{Destination: destination, Children: []Inline{&StringInline{text}}}, len(text)
+	}
+	return nil, 0
+}
+
+// extendedEmailAutolinkParser parses email autolinks. It is triggered by the
+// '@', and claims the local part of the address by backtracking.
+type extendedEmailAutolinkParser struct{}
+
+func (extendedEmailAutolinkParser) Parse(s InlineParserState) (Inline, int) {
+	return nil, 0
+}
+
+func (extendedEmailAutolinkParser) ParseBacktracking(s InlineParserState, pending int) (Inline, int, int) {
+	data, pos := s.Data(), s.Pos()
+	if s.InLinkText() {
+		return nil, 0, 0
+	}
+	// "One ore more characters which are alphanumeric, or ., -, _, or +."
+	start := pos
+	for start > pos-pending && isEmailLocalChar(data[start-1]) {
+		start--
+	}
+	if start == pos || !canStartAutolink(data, start) {
+		return nil, 0, 0
+	}
+	m := autolinkEmailDomainRe.Find(data[pos:])
+	if m == nil {
+		return nil, 0, 0
+	}
+	// "., -, and _ can occur on both sides of the @, but only . may occur at
+	// the end of the email address, in which case it will not be considered
+	// part of the address".
+	if last := m[len(m)-1]; last == '-' || last == '_' {
+		return nil, 0, 0
+	}
+	address := data[start : pos+len(m)]
+	return &Link{Destination: append([]byte("mailto:"), address...), Children: []Inline{&StringInline{address}}}, pos - start, len(m)
+}
+
+func isEmailLocalChar(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '.' || c == '-' || c == '_' || c == '+'
+}
+
+// isValidAutolinkDomain returns whether the domain of a URL is valid. "There
+// must be at least one period, and no underscores may be present in the last
+// two segments of the domain." For www. links, the www is the first segment.
+func isValidAutolinkDomain(domain []byte, www bool) bool {
+	segments := bytes.Split(domain, []byte("."))
+	if www {
+		segments = append([][]byte{[]byte("www")}, segments...)
+	}
+	if len(segments) < 2 {
+		return false
+	}
+	for _, segment := range segments[len(segments)-2:] {
+		if len(segment) == 0 || bytes.IndexByte(segment, '_') >= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// trimAutolink removes the characters from the end of an extended autolink
+// that are not considered part of it.
+func trimAutolink(link []byte) []byte {
+	for len(link) > 0 {
+		switch link[len(link)-1] {
+		// "Trailing punctuation (specifically, ?, !, ., ,, :, *, _, and ~)
+		// will not be considered part of the autolink, though they may be
+		// included in the interior of the link".
+		case '?', '!', '.', ',', ':', '*', '_', '~':
+			link = link[:len(link)-1]
+		// "When an autolink ends in ), we scan the entire autolink for the
+		// total number of parentheses. If there is a greater number of
+		// closing parentheses than opening ones, we don’t consider the
+		// unmatched trailing parentheses part of the autolink".
+		case ')':
+			if bytes.Count(link, []byte("(")) >= bytes.Count(link, []byte(")")) {
+				return link
+			}
+			link = link[:len(link)-1]
+		// "If an autolink ends in a semicolon (;), we check to see if it
+		// appears to resemble an entity reference; if the preceding text is &
+		// followed by one or more alphanumeric characters. If so, it is
+		// excluded from the autolink".
+		case ';':
+			m := autolinkEntityRe.FindIndex(link)
+			if m == nil {
+				return link
+			}
+			link = link[:m[0]]
+		default:
+			return link
+		}
+	}
+	return link
+}
@@ -0,0 +1,47 @@
+package commonmark
+
+import (
+	"testing"
+)
+
+func TestExtendedAutolinks(t *testing.T) {
+	// Examples mostly taken from the GFM spec.
+	runHTMLTests(t, New(WithExtensions(ExtendedAutolinks)), []htmlTest{
+		{"www.commonmark.org\n", "<p><a href=\"http://www.commonmark.org\">www.commonmark.org</a></p>\n"},
+		{"Visit www.commonmark.org/help for more information.\n", "<p>Visit <a href=\"http://www.commonmark.org/help\">www.commonmark.org/help</a> for more information.</p>\n"},
+		{"Visit www.commonmark.org.\n\nVisit www.commonmark.org/a.b.\n", "<p>Visit <a href=\"http://www.commonmark.org\">www.commonmark.org</a>.</p>\n<p>Visit <a href=\"http://www.commonmark.org/a.b\">www.commonmark.org/a.b</a>.</p>\n"},
+		{"www.google.com/search?q=Markup+(business)\n\nwww.google.com/search?q=Markup+(business)))\n\n(www.google.com/search?q=Markup+(business))\n\n(www.google.com/search?q=Markup+(business)\n",
+			"<p><a href=\"http://www.google.com/search?q=Markup+(business)\">www.google.com/search?q=Markup+(business)</a></p>\n" +
+				"<p><a href=\"http://www.google.com/search?q=Markup+(business)\">www.google.com/search?q=Markup+(business)</a>))</p>\n" +
+				"<p>(<a href=\"http://www.google.com/search?q=Markup+(business)\">www.google.com/search?q=Markup+(business)</a>)</p>\n" +
+				"<p>(<a href=\"http://www.google.com/search?q=Markup+(business)\">www.google.com/search?q=Markup+(business)</a></p>\n"},
+		{"www.google.com/search?q=(business))+ok\n", "<p><a href=\"http://www.google.com/search?q=(business))+ok\">www.google.com/search?q=(business))+ok</a></p>\n"},
+		{"www.google.com/search?q=commonmark&hl=en\n\nwww.google.com/search?q=commonmark&hl;\n",
+			"<p><a href=\"http://www.google.com/search?q=commonmark&amp;hl=en\">www.google.com/search?q=commonmark&amp;hl=en</a></p>\n" +
+				"<p><a href=\"http://www.google.com/search?q=commonmark\">www.google.com/search?q=commonmark</a>&amp;hl;</p>\n"},
+		{"www.commonmark.org/he<lp\n", "<p><a href=\"http://www.commonmark.org/he\">www.commonmark.org/he</a>&lt;lp</p>\n"},
+		{"http://commonmark.org\n\n(Visit https://encrypted.google.com/search?q=Markup+(business))\n\nAnonymous FTP is available at ftp://foo.bar.baz.\n",
+			"<p><a href=\"http://commonmark.org\">http://commonmark.org</a></p>\n" +
+				"<p>(Visit <a href=\"https://encrypted.google.com/search?q=Markup+(business)\">https://encrypted.google.com/search?q=Markup+(business)</a>)</p>\n" +
+				"<p>Anonymous FTP is available at <a href=\"ftp://foo.bar.baz\">ftp://foo.bar.baz</a>.</p>\n"},
+		{"foo@bar.baz\n", "<p><a href=\"mailto:foo@bar.baz\">foo@bar.baz</a></p>\n"},
+		{"hello@mail+xyz.example isn't valid, but hello+xyz@mail.example is.\n", "<p>hello@mail+xyz.example isn't valid, but <a href=\"mailto:hello+xyz@mail.example\">hello+xyz@mail.example</a> is.</p>\n"},
+		{"a.b-c_d@a.b\n\na.b-c_d@a.b.\n\na.b-c_d@a.b-\n\na.b-c_d@a.b_\n",
+			"<p><a href=\"mailto:a.b-c_d@a.b\">a.b-c_d@a.b</a></p>\n<p><a href=\"mailto:a.b-c_d@a.b\">a.b-c_d@a.b</a>.</p>\n<p>a.b-c_d@a.b-</p>\n<p>a.b-c_d@a.b_</p>\n"},
+		{"http://localhost/ www.a_b.c_d x.www.a.com `www.a.com`\n", "<p>http://localhost/ www.a_b.c_d x.www.a.com <code>www.a.com</code></p>\n"},
+		{"https://ja.wikipedia.org/wiki/日本\n", "<p><a href=\"https://ja.wikipedia.org/wiki/%E6%97%A5%E6%9C%AC\">https://ja.wikipedia.org/wiki/日本</a></p>\n"},
+	})
+	runHTMLTests(t, New(WithExtensions(ExtendedAutolinks)), []htmlTest{
+		{"[see www.x.com](/u)\n", "<p><a href=\"/u\">see www.x.com</a></p>\n"},
+		{"[mail a@b.com](/u) or a@b.com\n", "<p><a href=\"/u\">mail a@b.com</a> or <a href=\"mailto:a@b.com\">a@b.com</a></p>\n"},
+		{"![http://x.com/a.png](/i.png)\n", "<p><img src=\"/i.png\" alt=\"http://x.com/a.png\" /></p>\n"},
+		{"`x`a@b.com &amp;a@b.com (a@b.com)\n", "<p><code>x</code>a@b.com &amp;a@b.com (<a href=\"mailto:a@b.com\">a@b.com</a>)</p>\n"},
+		{"@b.com a@ @\n", "<p>@b.com a@ @</p>\n"},
+		{"WWW.X.COM Www.x.com HTTP://X.COM/A Ftp://x.com\n", "<p><a href=\"http://WWW.X.COM\">WWW.X.COM</a> <a href=\"http://Www.x.com\">Www.x.com</a> <a href=\"HTTP://X.COM/A\">HTTP://X.COM/A</a> <a href=\"Ftp://x.com\">Ftp://x.com</a></p>\n"},
+		{"see a[i www.x.com\n", "<p>see a[i <a href=\"http://www.x.com\">www.x.com</a></p>\n"},
+		{"[a] www.x.com [b [c](/u) www.y.com ](/v)\n", "<p>[a] <a href=\"http://www.x.com\">www.x.com</a> [b <a href=\"/u\">c</a> <a href=\"http://www.y.com\">www.y.com</a> ](/v)</p>\n"},
+	})
+	runHTMLTests(t, New(WithExtensions(ExtendedAutolinks, Strikethrough)), []htmlTest{
+		{"~~www.a.com~~ http://a.com/~x~\n", "<p><del><a href=\"http://www.a.com\">www.a.com</a></del> <a href=\"http://a.com/~x\">http://a.com/~x</a>~</p>\n"},
+	})
+}
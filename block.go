@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"log"
 	"regexp"
+	"strconv"
 )
 
 // Block represents a node in the parse tree.
@@ -22,6 +23,10 @@ type Block interface {
 	// ReplaceLastChild replaces the last child block with the given one.
 	ReplaceLastChild(Block)
 
+	// SetChildren replaces the entire list of child blocks, e.g. when a
+	// Transform restructures the tree.
+	SetChildren([]Block)
+
 	// AppendLine appends the given line to the list of lines.
 	AppendLine([]byte)
 
@@ -36,119 +41,391 @@ type Block interface {
 	CanContain(Block) bool
 }
 
-// block implements the common part of the Block interface.
-type block struct {
-	children      []Block
-	content       []byte
-	inlineContent Inline
+// InlineContainer is implemented by blocks whose content is parsed as
+// inlines, such as paragraphs and headers. Once all blocks have been parsed,
+// the raw content of each InlineContainer is parsed, and the result is passed
+// to SetInlineContent.
+type InlineContainer interface {
+	// RawInlineContent returns the text to be parsed as inline content.
+	RawInlineContent() []byte
+
+	// SetInlineContent stores the parsed inline content.
+	SetInlineContent(Inline)
+
+	// ParsedInlineContent returns the inline content stored by
+	// SetInlineContent, or nil if it has not been parsed yet.
+	ParsedInlineContent() Inline
 }
 
-func (b *block) Children() []Block {
+// BaseBlock implements the common part of the Block interface. Custom Block
+// types can embed it, and override only the methods they need to.
+type BaseBlock struct {
+	children []Block
+	// Content holds the raw text lines of leaf blocks.
+	Content []byte
+	// InlineContent holds the parsed inline content of blocks that have
+	// any, such as paragraphs and headers.
+	InlineContent Inline
+}
+
+func (b *BaseBlock) Children() []Block {
 	return b.children
 }
 
-func (b *block) AppendChild(child Block) {
+func (b *BaseBlock) AppendChild(child Block) {
 	b.children = append(b.children, child)
 }
 
-func (b *block) ReplaceLastChild(child Block) {
+func (b *BaseBlock) ReplaceLastChild(child Block) {
 	b.children[len(b.children)-1] = child
 }
 
-func (b *block) AppendLine(line []byte) {
-	b.content = append(b.content, line...)
+func (b *BaseBlock) SetChildren(children []Block) {
+	b.children = children
+}
+
+func (b *BaseBlock) AppendLine(line []byte) {
+	b.Content = append(b.Content, line...)
 }
 
-func (b *block) AcceptsLines() bool {
+func (b *BaseBlock) AcceptsLines() bool {
 	return false
 }
 
-func (b *block) AcceptsLiteralLines() bool {
+func (b *BaseBlock) AcceptsLiteralLines() bool {
 	return false
 }
 
-func (b *block) CanContain(Block) bool {
+func (b *BaseBlock) CanContain(Block) bool {
 	return false
 }
 
-// document is the root node of the parse tree.
-type document struct {
-	block
+// Document is the root node of the parse tree.
+type Document struct {
+	BaseBlock
+	// FrontMatter is the front matter of the document, or nil if it has
+	// none; see WithFrontMatter.
+	FrontMatter *FrontMatter
 }
 
-func (d *document) CanContain(Block) bool {
+func (d *Document) CanContain(Block) bool {
 	return true
 }
 
-// horizontalRule is a horizontal rule.
+// HorizontalRule is a horizontal rule.
 //
 // "A line consisting of 0-3 spaces of indentation, followed by a sequence of
 // three or more matching -, _, or * characters, each followed optionally by
 // any number of spaces, forms a horizontal rule."
-type horizontalRule struct {
-	block
+type HorizontalRule struct {
+	BaseBlock
 }
 
-// atxHeader is a header marked with # characters.
+// Header is a header marked with # characters (an ATX header) or underlined
+// with = or - characters (a setext header).
 //
 // "An ATX header consists of a string of characters, parsed as inline content,
 // between an opening sequence of 1–6 unescaped # characters and an optional
 // closing sequence of any number of # characters."
-type atxHeader struct {
-	block
-	level int
+//
+// "A setext header consists of a line of text, containing at least one
+// nonspace character, with no more than 3 spaces indentation, followed by a
+// setext header underline. [...] The header is a level 1 header if =
+// characters are used, and a level 2 header if - characters are used."
+type Header struct {
+	BaseBlock
+	// Level is the header level, 1-6.
+	Level int
+	// ID is the value of the header's id attribute, if it has one; see
+	// WithHeaderIDs.
+	ID string
+	// Attributes holds the header's other HTML attributes; see Attributes.
+	Attributes []Attribute
+}
+
+func (h *Header) RawInlineContent() []byte {
+	return h.Content
+}
+
+func (h *Header) SetInlineContent(inline Inline) {
+	h.InlineContent = inline
+}
+
+func (h *Header) ParsedInlineContent() Inline {
+	return h.InlineContent
 }
 
-// indentedCodeBlock represents an indented code block.
+// IndentedCodeBlock represents an indented code block.
 //
 // "An indented code block is composed of one or more indented chunks separated
 // by blank lines. An indented chunk is a sequence of non-blank lines, each
 // indented four or more spaces."
-type indentedCodeBlock struct {
-	block
+type IndentedCodeBlock struct {
+	BaseBlock
+}
+
+func (c *IndentedCodeBlock) AcceptsLines() bool {
+	return true
+}
+
+func (c *IndentedCodeBlock) AcceptsLiteralLines() bool {
+	return true
+}
+
+// FencedCodeBlock represents a fenced code block.
+//
+// "A code fence is a sequence of at least three consecutive backtick
+// characters (`) or tildes (~). (Tildes and backticks cannot be mixed.) A
+// fenced code block begins with a code fence, indented no more than three
+// spaces."
+type FencedCodeBlock struct {
+	BaseBlock
+	// Info is the info string after the opening fence, with leading and
+	// trailing spaces removed and backslash escapes and entities processed.
+	// "The first word of the info string is typically used to specify the
+	// language of the code sample".
+	Info []byte
+	// Attributes holds the HTML attributes of the code block; see
+	// Attributes.
+	Attributes []Attribute
+
+	// fenceChar, fenceLength and fenceIndent describe the opening fence.
+	fenceChar   byte
+	fenceLength int
+	fenceIndent int
+	// closed is set once the closing fence has been seen.
+	closed bool
+}
+
+func (c *FencedCodeBlock) AcceptsLines() bool {
+	return true
+}
+
+func (c *FencedCodeBlock) AcceptsLiteralLines() bool {
+	return true
+}
+
+// Language returns the first word of the info string, or nil if it is empty.
+func (c *FencedCodeBlock) Language() []byte {
+	if fields := bytes.Fields(c.Info); len(fields) > 0 {
+		return fields[0]
+	}
+	return nil
+}
+
+// HTMLBlock represents a block of raw HTML.
+//
+// "An HTML block begins with an HTML block tag, HTML comment, processing
+// instruction, declaration, or CDATA section. It ends when a blank line or the
+// end of the input is encountered."
+type HTMLBlock struct {
+	BaseBlock
 }
 
-func (c *indentedCodeBlock) AcceptsLines() bool {
+func (h *HTMLBlock) AcceptsLines() bool {
 	return true
 }
 
-func (c *indentedCodeBlock) AcceptsLiteralLines() bool {
+func (h *HTMLBlock) AcceptsLiteralLines() bool {
 	return true
 }
 
-// paragraph represents a paragraph of text.
+// Paragraph represents a paragraph of text.
 //
 // "A sequence of non-blank lines that cannot be interpreted as other kinds of
 // blocks forms a paragraph."
-type paragraph struct {
-	block
+type Paragraph struct {
+	BaseBlock
+	// Attributes holds the HTML attributes of the paragraph; see Attributes.
+	Attributes []Attribute
+}
+
+// RawInlineContent returns the paragraph's content without final spaces.
+// "Final spaces are stripped before inline parsing, so a paragraph that ends
+// with two or more spaces will not end with a hard line break."
+func (p *Paragraph) RawInlineContent() []byte {
+	return bytes.TrimRight(p.Content, " ")
+}
+
+func (p *Paragraph) SetInlineContent(inline Inline) {
+	p.InlineContent = inline
+}
+
+func (p *Paragraph) ParsedInlineContent() Inline {
+	return p.InlineContent
 }
 
-func (p *paragraph) AppendLine(line []byte) {
-	p.block.AppendLine(bytes.TrimLeft(line, " "))
+func (p *Paragraph) AppendLine(line []byte) {
+	p.BaseBlock.AppendLine(bytes.TrimLeft(line, " "))
 }
 
-func (p *paragraph) AcceptsLines() bool {
+func (p *Paragraph) AcceptsLines() bool {
 	return true
 }
 
-// blockQuote represents a block quote; roughly, a series of lines starting
+// BlockQuote represents a block quote; roughly, a series of lines starting
 // with '>'.
-type blockQuote struct {
-	block
+type BlockQuote struct {
+	BaseBlock
+}
+
+func (q *BlockQuote) CanContain(Block) bool {
+	return true
+}
+
+// List is a bullet list or an ordered list. Its children are ListItems.
+//
+// "A list is a sequence of one or more list items of the same type. The list
+// items may be separated by single blank lines, but two blank lines end all
+// containing lists."
+type List struct {
+	BaseBlock
+	// Ordered is true for ordered lists, and false for bullet lists.
+	Ordered bool
+	// Start is the start number of an ordered list: "The start number of an
+	// ordered list is determined by the list number of its initial list
+	// item."
+	Start int
+	// Tight is true for tight lists: "A list is loose if it any of its
+	// constituent list items are separated by blank lines, or if any of its
+	// constituent list items directly contain two block-level elements with
+	// a blank line between them. Otherwise a list is tight."
+	Tight bool
+
+	// marker is the bullet character of a bullet list, or the delimiter ('.'
+	// or ')') of an ordered list.
+	marker byte
+	blanks blankTracker
+}
+
+func (l *List) CanContain(b Block) bool {
+	_, isListItem := b.(*ListItem)
+	return isListItem
 }
 
-func (q *blockQuote) CanContain(Block) bool {
+// ListItem is an item of a List, which can contain any blocks.
+type ListItem struct {
+	BaseBlock
+	// Tight is true if the item belongs to a tight list. Its paragraphs are
+	// then rendered without <p> tags.
+	Tight bool
+	// Task is true if the item is a task list item, which starts with a
+	// checkbox (see TaskLists), and Checked is true if the box is checked.
+	Task, Checked bool
+
+	// contentOffset is the indentation that continuation lines need to be
+	// part of the item: the width of the list marker, including any
+	// indentation before it and the spaces after it.
+	contentOffset int
+	blanks        blankTracker
+}
+
+func (i *ListItem) CanContain(Block) bool {
 	return true
 }
 
+// blankTracker finds out whether a blank line in a container block is
+// followed by a new child block, which makes the containing list loose.
+type blankTracker struct {
+	afterBlank bool
+	// checking is set for the first nonblank line after a blank line; if the
+	// block has more than numChildren children after that line, a new child
+	// was started.
+	checking    bool
+	numChildren int
+	// loose is set once a blank line between two children has been found.
+	loose bool
+}
+
+// line must be called for each line of the container block b, before any
+// new children are added to it, and with nil when the block is closed.
+func (t *blankTracker) line(b Block, line []byte) {
+	if t.checking && len(b.Children()) > t.numChildren {
+		t.loose = true
+	}
+	t.checking = false
+	if line == nil {
+		return
+	}
+	if isBlank(line) {
+		t.afterBlank = true
+	} else if t.afterBlank {
+		t.afterBlank = false
+		t.checking = true
+		t.numChildren = len(b.Children())
+	}
+}
+
+// BlockParser recognizes and parses one type of Block. The built-in block
+// types are parsed by BlockParsers too, so custom parsers work in exactly the
+// same way.
+//
+// For each line of input, the parser first calls Continue for every open
+// block, from the outside in; each call can strip the block's markers (like
+// the '>' of a block quote) from the line. Then, unless the deepest open block
+// accepts literal lines, it calls Start on each BlockParser in turn, until one
+// of them starts a new block. This repeats as long as new container blocks are
+// being started. Any rest of the line is added to the deepest open block.
+type BlockParser interface {
+	// Start attempts to start a new block on the given line. If it does, it
+	// adds the block using AddBlock or ReplaceOpenBlock on the state, and
+	// returns the rest of the line and true. If the new block consumes the
+	// entire line, the returned rest must be nil. If no block starts, Start
+	// returns false and must not modify the state.
+	Start(s BlockParserState, line []byte) ([]byte, bool)
+
+	// Continue returns whether the given open block, which was added by this
+	// parser, continues on the given line. If so, it also returns the rest of
+	// the line after any of the block's markers.
+	Continue(b Block, line []byte) ([]byte, bool)
+
+	// Close is called when the given block, which was added by this parser,
+	// is closed. No more lines will be added to it.
+	Close(b Block)
+}
+
+// BlockParserState is the state of the block parsing phase, as exposed to
+// BlockParsers.
+type BlockParserState interface {
+	// OpenBlock returns the deepest open block.
+	OpenBlock() Block
+
+	// AddBlock adds a new block as a child of the deepest open block that can
+	// contain it, closing any open blocks below that one. The new block
+	// becomes the deepest open block. The given parser is used to continue
+	// and close it.
+	AddBlock(b Block, parser BlockParser)
+
+	// ReplaceOpenBlock replaces the deepest open block, which is discarded
+	// without being closed, by the given one.
+	ReplaceOpenBlock(b Block, parser BlockParser)
+}
+
+// defaultBlockParsers returns the parsers for the built-in block types, sorted
+// by priority. The priorities are documented at Converter.AddBlockParser.
+func defaultBlockParsers() []prioritizedBlockParser {
+	return []prioritizedBlockParser{
+		{indentedCodeBlockParser{}, 100},
+		{blockQuoteParser{}, 200},
+		{fencedCodeBlockParser{}, 250},
+		{htmlBlockParser{}, 300},
+		{atxHeaderParser{}, 400},
+		{setextHeaderParser{}, 500},
+		{horizontalRuleParser{}, 600},
+		{listItemParser{}, 650},
+		{paragraphParser{}, 1000},
+	}
+}
+
 // parseBlocks performs the first parsing pass: turning the document into a
 // tree of blocks. Inline content is not parsed at this time.
-func parseBlocks(data []byte) (*document, error) {
-	doc := &document{}
+func parseBlocks(data []byte, options *parseOptions) (*Document, error) {
+	doc := &Document{}
 	parser := blockParser{
-		doc:        doc,
-		openBlocks: []Block{doc},
+		doc:         doc,
+		parsers:     options.blockParsers,
+		openBlocks:  []Block{doc},
+		openParsers: []BlockParser{nil},
 	}
 	if err := parser.parse(data); err != nil {
 		return nil, err
@@ -157,15 +434,26 @@ func parseBlocks(data []byte) (*document, error) {
 }
 
 type blockParser struct {
-	doc        *document
-	openBlocks []Block
+	doc     *Document
+	parsers []prioritizedBlockParser
+
+	// openBlocks and openParsers are parallel; each open block is continued
+	// and closed by the corresponding parser. The root is never closed, so it
+	// has no parser.
+	openBlocks  []Block
+	openParsers []BlockParser
+}
+
+func (p *blockParser) OpenBlock() Block {
+	return p.openBlocks[len(p.openBlocks)-1]
 }
 
-func (p *blockParser) addChild(child Block) {
+func (p *blockParser) AddBlock(child Block, parser BlockParser) {
 	for i := len(p.openBlocks) - 1; i >= 0; i-- {
 		if p.openBlocks[i].CanContain(child) {
 			p.openBlocks[i].AppendChild(child)
 			p.openBlocks = append(p.openBlocks, child)
+			p.openParsers = append(p.openParsers, parser)
 			return
 		} else {
 			p.closeLastBlock()
@@ -173,18 +461,19 @@ func (p *blockParser) addChild(child Block) {
 	}
 }
 
-func (p *blockParser) closeLastBlock() {
-	p.openBlocks = p.openBlocks[:len(p.openBlocks)-1]
-}
-
-func (p *blockParser) openBlock() Block {
-	return p.openBlocks[len(p.openBlocks)-1]
-}
-
-func (p *blockParser) replaceOpenBlock(b Block) {
+func (p *blockParser) ReplaceOpenBlock(b Block, parser BlockParser) {
 	assertf(len(p.openBlocks) > 1, "cannot replace document root")
 	p.openBlocks[len(p.openBlocks)-2].ReplaceLastChild(b)
 	p.openBlocks[len(p.openBlocks)-1] = b
+	p.openParsers[len(p.openParsers)-1] = parser
+}
+
+func (p *blockParser) closeLastBlock() {
+	last := len(p.openBlocks) - 1
+	assertf(last > 0, "cannot close document root")
+	p.openParsers[last].Close(p.openBlocks[last])
+	p.openBlocks = p.openBlocks[:last]
+	p.openParsers = p.openParsers[:last]
 }
 
 func (p *blockParser) parse(data []byte) error {
@@ -200,83 +489,44 @@ func (p *blockParser) parse(data []byte) error {
 		// may be altered in one or more of the following ways:"
 
 		// "1. One or more open blocks may be closed."
-		var openBlock Block
-		var i int
-		for i, openBlock = range p.openBlocks {
-			indent := indentation(line)
-			blank := line[indent] == '\n'
-
-			allMatched := true
-			switch openBlock.(type) {
-			case *indentedCodeBlock:
-				if indent >= 4 || blank {
-					if len(line) > 4 {
-						line = line[4:]
-					} else {
-						line = line[len(line)-1:]
-					}
-				} else {
-					allMatched = false
-				}
-			case *paragraph:
-				if blank {
-					allMatched = false
-				}
-			case *blockQuote:
-				// "3. Consecutiveness. A document cannot contain two block
-				// quotes in a row unless there is a blank line between them."
-				if blank {
-					allMatched = false
-				} else if line[indent] == '>' {
-					line = stripBlockQuoteMarker(line)
-				}
-			}
-			if !allMatched {
-				assertf(i > 0, "allMatched should not become false at the document root")
-				i--
+		matched := 1
+		for ; matched < len(p.openBlocks); matched++ {
+			rest, ok := p.openParsers[matched].Continue(p.openBlocks[matched], line)
+			if !ok {
 				break
 			}
+			line = rest
 		}
-
-		for len(p.openBlocks) > i+1 {
+		// "[...] paragraph continuation text [...] is text that will be
+		// parsed as part of the content of a paragraph, but is not the
+		// beginning of a new paragraph." Such a lazy line is added to the
+		// open paragraph, even if the blocks containing it did not match.
+		if matched < len(p.openBlocks) && p.isLazyContinuation(line) {
+			p.OpenBlock().AppendLine(line)
+			continue
+		}
+		for len(p.openBlocks) > matched {
 			p.closeLastBlock()
 		}
 
 		// "2. One or more new blocks may be created as children of the last open block."
-		for !p.openBlock().AcceptsLiteralLines() {
-			openBlock := p.openBlock()
-			par, isParagraph := openBlock.(*paragraph)
-			if !isParagraph && indentation(line) >= 4 {
-				p.addChild(&indentedCodeBlock{})
-				line = line[4:]
-			} else if line[indentation(line)] == '>' {
-				p.addChild(&blockQuote{})
-				line = stripBlockQuoteMarker(line)
-			} else if level, content := parseATXHeader(line); level > 0 {
-				p.addChild(&atxHeader{level: level, block: block{content: content}})
-				p.closeLastBlock()
-				line = nil
-				break
-			} else if level := parseSetextUnderline(line); isParagraph && level > 0 && hasOneLine(par.content) {
-				p.replaceOpenBlock(&atxHeader{level: level, block: block{content: par.content}})
-				p.closeLastBlock()
-				line = nil
-				break
-			} else if isHorizontalRule(line) {
-				p.addChild(&horizontalRule{})
-				p.closeLastBlock()
+		for !p.OpenBlock().AcceptsLiteralLines() {
+			if isBlank(line) {
 				line = nil
 				break
-			} else if isBlank(line) {
-				line = nil
-				break
-			} else if !openBlock.AcceptsLines() {
-				p.addChild(&paragraph{})
-			} else {
+			}
+			started := false
+			for _, parser := range p.parsers {
+				var rest []byte
+				if rest, started = parser.parser.Start(p, line); started {
+					line = rest
+					break
+				}
+			}
+			if !started || line == nil {
 				break
 			}
-
-			if p.openBlock().AcceptsLines() {
+			if p.OpenBlock().AcceptsLines() {
 				break
 			}
 		}
@@ -287,16 +537,350 @@ func (p *blockParser) parse(data []byte) error {
 
 		// "3. Text may be added to the last (deepest) open block remaining on
 		// the tree."
-		openBlock = p.openBlock()
+		openBlock := p.OpenBlock()
 		assertf(openBlock.AcceptsLines(), "remaining types of block should all accept lines, but %T does not (line: %q)", openBlock, line)
 		openBlock.AppendLine(line)
 	}
 	if err := scanner.Err(); err != nil {
 		return err
 	}
+
+	// "Blocks are closed at the end of the input."
+	for len(p.openBlocks) > 1 {
+		p.closeLastBlock()
+	}
 	return nil
 }
 
+// isLazyContinuation returns whether the line is paragraph continuation text
+// for the deepest open block, i.e. whether that block is a paragraph and the
+// line does not start any other type of block.
+func (p *blockParser) isLazyContinuation(line []byte) bool {
+	if _, isParagraph := p.OpenBlock().(*Paragraph); !isParagraph || isBlank(line) {
+		return false
+	}
+	probe := &probeState{openBlock: p.OpenBlock()}
+	for _, parser := range p.parsers {
+		if _, started := parser.parser.Start(probe, line); started {
+			_, isParagraph := probe.added.(*Paragraph)
+			return isParagraph
+		}
+	}
+	return true
+}
+
+// probeState is a BlockParserState that records the blocks that parsers add,
+// without modifying the document. It is used to find out whether a line
+// would start a new block.
+type probeState struct {
+	openBlock Block
+	added     Block
+}
+
+func (s *probeState) OpenBlock() Block {
+	return s.openBlock
+}
+
+func (s *probeState) AddBlock(b Block, parser BlockParser) {
+	s.added = b
+}
+
+func (s *probeState) ReplaceOpenBlock(b Block, parser BlockParser) {
+	s.added = b
+}
+
+// leafBlockParser implements the parts of BlockParser for leaf blocks that
+// consist of a single line, and are therefore closed on the next line.
+type leafBlockParser struct{}
+
+func (leafBlockParser) Continue(b Block, line []byte) ([]byte, bool) {
+	return nil, false
+}
+
+func (leafBlockParser) Close(b Block) {}
+
+type indentedCodeBlockParser struct{}
+
+func (indentedCodeBlockParser) Start(s BlockParserState, line []byte) ([]byte, bool) {
+	if _, isParagraph := s.OpenBlock().(*Paragraph); isParagraph || indentation(line) < 4 {
+		return nil, false
+	}
+	s.AddBlock(&IndentedCodeBlock{}, indentedCodeBlockParser{})
+	return line[4:], true
+}
+
+func (indentedCodeBlockParser) Continue(b Block, line []byte) ([]byte, bool) {
+	indent := indentation(line)
+	if indent < 4 && line[indent] != '\n' {
+		return nil, false
+	}
+	if len(line) > 4 {
+		return line[4:], true
+	}
+	return line[len(line)-1:], true
+}
+
+func (indentedCodeBlockParser) Close(b Block) {}
+
+type blockQuoteParser struct{}
+
+func (blockQuoteParser) Start(s BlockParserState, line []byte) ([]byte, bool) {
+	if line[indentation(line)] != '>' {
+		return nil, false
+	}
+	s.AddBlock(&BlockQuote{}, blockQuoteParser{})
+	return stripBlockQuoteMarker(line), true
+}
+
+func (blockQuoteParser) Continue(b Block, line []byte) ([]byte, bool) {
+	// A blank line ends the block quote, so "3. Consecutiveness. A document
+	// cannot contain two block quotes in a row unless there is a blank line
+	// between them." Other lines without a '>' can only continue the block
+	// quote lazily.
+	if indent := indentation(line); indent > 3 || line[indent] != '>' {
+		return nil, false
+	}
+	return stripBlockQuoteMarker(line), true
+}
+
+func (blockQuoteParser) Close(b Block) {}
+
+type fencedCodeBlockParser struct{}
+
+// codeFenceRe matches an opening code fence and its info string.
+var codeFenceRe = regexp.MustCompile("^( {0,3})(`{3,}|~{3,})(.*)\n$")
+
+func (fencedCodeBlockParser) Start(s BlockParserState, line []byte) ([]byte, bool) {
+	m := codeFenceRe.FindSubmatch(line)
+	if m == nil {
+		return nil, false
+	}
+	fence, info := m[2], m[3]
+	// "The line with the opening code fence may optionally contain some text
+	// following the code fence; this is trimmed of leading and trailing
+	// spaces and called the info string. The info string may not contain any
+	// backtick characters."
+	if fence[0] == '`' && bytes.IndexByte(info, '`') >= 0 {
+		return nil, false
+	}
+	s.AddBlock(&FencedCodeBlock{
+		Info:        unescapeString(bytes.Trim(info, " ")),
+		fenceChar:   fence[0],
+		fenceLength: len(fence),
+		fenceIndent: len(m[1]),
+	}, fencedCodeBlockParser{})
+	return nil, true
+}
+
+func (fencedCodeBlockParser) Continue(b Block, line []byte) ([]byte, bool) {
+	code := b.(*FencedCodeBlock)
+	if code.closed {
+		return nil, false
+	}
+	// "The closing code fence [...] must be at least as long as the opening
+	// fence", and "may be indented up to three spaces, and may be followed
+	// only by spaces".
+	indent := indentation(line)
+	if indent <= 3 {
+		fence := line[indent:]
+		length := 0
+		for length < len(fence) && fence[length] == code.fenceChar {
+			length++
+		}
+		if length >= code.fenceLength && isBlank(fence[length:]) {
+			code.closed = true
+			return nil, true
+		}
+	}
+	// "If the leading code fence is indented N spaces, then up to N spaces of
+	// indentation are removed from each line of the content (if present)."
+	if indent > code.fenceIndent {
+		indent = code.fenceIndent
+	}
+	return line[indent:], true
+}
+
+func (fencedCodeBlockParser) Close(b Block) {}
+
+type listParser struct{}
+
+// Start never starts a list by itself; lists are started by listItemParser.
+func (listParser) Start(s BlockParserState, line []byte) ([]byte, bool) {
+	return nil, false
+}
+
+// Continue continues the list on every line except a second blank line in a
+// row. Whether the line continues the list in the end is up to its last
+// item, or else to listItemParser.
+func (listParser) Continue(b Block, line []byte) ([]byte, bool) {
+	list := b.(*List)
+	if isBlank(line) && list.blanks.afterBlank {
+		return nil, false
+	}
+	list.blanks.line(list, line)
+	return line, true
+}
+
+func (listParser) Close(b Block) {
+	list := b.(*List)
+	list.blanks.line(list, nil)
+	loose := list.blanks.loose
+	for _, child := range list.Children() {
+		loose = loose || child.(*ListItem).blanks.loose
+	}
+	list.Tight = !loose
+	for _, child := range list.Children() {
+		child.(*ListItem).Tight = list.Tight
+	}
+}
+
+type listItemParser struct{}
+
+// Start starts a list item, and also a list if the item does not continue an
+// open list of the same type.
+func (listItemParser) Start(s BlockParserState, line []byte) ([]byte, bool) {
+	m := listMarkerRe.FindSubmatchIndex(line)
+	if m == nil {
+		return nil, false
+	}
+	// The match includes the space or newline after the marker.
+	markerEnd := m[1] - 1
+	ordered := m[4] >= 0
+	var marker byte
+	var start int
+	if ordered {
+		marker = line[m[5]-1]
+		start, _ = strconv.Atoi(string(line[m[4] : m[5]-1]))
+	} else {
+		marker = line[m[2]]
+	}
+
+	// "M is a list marker M of width W followed by 0 < N < 5 spaces", unless
+	// the item starts with indented code or is empty, in which case N is 1.
+	contentOffset := markerEnd + 1
+	if spaces := indentation(line[markerEnd:]); spaces < 5 && line[markerEnd+spaces] != '\n' {
+		contentOffset = markerEnd + spaces
+	}
+
+	list, isList := s.OpenBlock().(*List)
+	if !isList || list.Ordered != ordered || list.marker != marker {
+		list = &List{Ordered: ordered, Start: start, marker: marker}
+		s.AddBlock(list, listParser{})
+	}
+	s.AddBlock(&ListItem{contentOffset: contentOffset}, listItemParser{})
+	if contentOffset >= len(line) {
+		return nil, true
+	}
+	return line[contentOffset:], true
+}
+
+// Continue continues the list item on blank lines and on lines that are
+// indented at least as far as its content.
+func (listItemParser) Continue(b Block, line []byte) ([]byte, bool) {
+	item := b.(*ListItem)
+	if isBlank(line) {
+		item.blanks.line(item, line)
+		if len(line) > item.contentOffset {
+			return line[item.contentOffset:], true
+		}
+		return line[len(line)-1:], true
+	}
+	if indentation(line) < item.contentOffset {
+		return nil, false
+	}
+	item.blanks.line(item, line)
+	return line[item.contentOffset:], true
+}
+
+func (listItemParser) Close(b Block) {
+	item := b.(*ListItem)
+	item.blanks.line(item, nil)
+}
+
+// listMarkerRe matches a list marker, indented by at most three spaces, and
+// followed by a space or the end of the line. "A bullet list marker is a -,
+// +, or * character. An ordered list marker is a sequence of one of more
+// digits (0-9), followed by either a . character or a ) character."
+var listMarkerRe = regexp.MustCompile(`^ {0,3}(?:([-+*])|([0-9]{1,9}[.)]))[ \n]`)
+
+type htmlBlockParser struct{}
+
+func (htmlBlockParser) Start(s BlockParserState, line []byte) ([]byte, bool) {
+	if !isHTMLBlockStart(line) {
+		return nil, false
+	}
+	// "The initial line may be indented up to three spaces, and subsequent
+	// lines may have any indentation."
+	s.AddBlock(&HTMLBlock{}, htmlBlockParser{})
+	return line, true
+}
+
+func (htmlBlockParser) Continue(b Block, line []byte) ([]byte, bool) {
+	return line, !isBlank(line)
+}
+
+func (htmlBlockParser) Close(b Block) {}
+
+type atxHeaderParser struct {
+	leafBlockParser
+}
+
+func (atxHeaderParser) Start(s BlockParserState, line []byte) ([]byte, bool) {
+	level, content := parseATXHeader(line)
+	if level <= 0 {
+		return nil, false
+	}
+	s.AddBlock(&Header{Level: level, BaseBlock: BaseBlock{Content: content}}, atxHeaderParser{})
+	return nil, true
+}
+
+type setextHeaderParser struct {
+	leafBlockParser
+}
+
+func (setextHeaderParser) Start(s BlockParserState, line []byte) ([]byte, bool) {
+	par, isParagraph := s.OpenBlock().(*Paragraph)
+	if !isParagraph || !hasOneLine(par.Content) {
+		return nil, false
+	}
+	level := parseSetextUnderline(line)
+	if level <= 0 {
+		return nil, false
+	}
+	s.ReplaceOpenBlock(&Header{Level: level, BaseBlock: BaseBlock{Content: par.Content}}, setextHeaderParser{})
+	return nil, true
+}
+
+type horizontalRuleParser struct {
+	leafBlockParser
+}
+
+func (horizontalRuleParser) Start(s BlockParserState, line []byte) ([]byte, bool) {
+	if !isHorizontalRule(line) {
+		return nil, false
+	}
+	s.AddBlock(&HorizontalRule{}, horizontalRuleParser{})
+	return nil, true
+}
+
+type paragraphParser struct{}
+
+// Start starts a paragraph on any line, unless the deepest open block already
+// accepts lines. It should therefore be the last parser to be tried.
+func (paragraphParser) Start(s BlockParserState, line []byte) ([]byte, bool) {
+	if s.OpenBlock().AcceptsLines() {
+		return nil, false
+	}
+	s.AddBlock(&Paragraph{}, paragraphParser{})
+	return line, true
+}
+
+func (paragraphParser) Continue(b Block, line []byte) ([]byte, bool) {
+	return line, !isBlank(line)
+}
+
+func (paragraphParser) Close(b Block) {}
+
 // indentation returns the index of the first non-space. If the line consists
 // entirely of spaces, it returns the index of the newline character.
 func indentation(line []byte) int {
@@ -402,6 +986,20 @@ func parseATXHeader(line []byte) (int, []byte) {
 	return level, line
 }
 
+// "An HTML block tag is an open tag or closing tag whose tag name is one of
+// the following (case-insensitive): [...]"
+const htmlBlockTagName = `(?:article|header|aside|hgroup|blockquote|hr|iframe|body|li|map|button|object|canvas|ol|caption|output|col|p|colgroup|pre|dd|progress|div|section|dl|table|td|dt|tbody|embed|textarea|fieldset|tfoot|figcaption|th|figure|thead|footer|tr|form|ul|h1|h2|h3|h4|h5|h6|video|script|style)`
+
+// htmlBlockStartRe matches the start of an HTML block. Like the reference
+// implementation, it does not require the tag to be complete: "An incomplete
+// HTML block tag may also start an HTML block".
+var htmlBlockStartRe = regexp.MustCompile(`(?i)^ {0,3}<(?:` + htmlBlockTagName + `[\s/>]|/` + htmlBlockTagName + `[\s>]|[?!])`)
+
+// isHTMLBlockStart returns whether the line starts an HTML block.
+func isHTMLBlockStart(line []byte) bool {
+	return htmlBlockStartRe.Match(line)
+}
+
 var setextUnderlineRe = regexp.MustCompile(`^ {0,3}(=+|-+) *\n$`)
 
 // parseSetextUnderline recognizes a setext header underline and returns its
@@ -415,7 +1013,7 @@ func parseSetextUnderline(line []byte) int {
 		case '-':
 			return 2
 		default:
-			assertf(false, "unexpected setext line character '%s'", m[1][0])
+			assertf(false, "unexpected setext line character '%c'", m[1][0])
 		}
 	}
 	return -1
@@ -0,0 +1,208 @@
+package commonmark
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// aside is a custom container block, marked by '|' at the start of each line.
+type aside struct {
+	BaseBlock
+}
+
+func (a *aside) CanContain(Block) bool {
+	return true
+}
+
+type asideParser struct{}
+
+func (asideParser) Start(s BlockParserState, line []byte) ([]byte, bool) {
+	if !bytes.HasPrefix(line, []byte("| ")) {
+		return nil, false
+	}
+	s.AddBlock(&aside{}, asideParser{})
+	return line[2:], true
+}
+
+func (asideParser) Continue(b Block, line []byte) ([]byte, bool) {
+	if !bytes.HasPrefix(line, []byte("| ")) {
+		return nil, false
+	}
+	return line[2:], true
+}
+
+func (asideParser) Close(b Block) {}
+
+func renderAside(r *HTMLRenderer, b Block, out io.Writer) {
+	io.WriteString(out, "<aside>\n")
+	r.RenderChildren(b, out)
+	io.WriteString(out, "</aside>\n")
+}
+
+// box is a custom container block, marked by "; " at the start of each line.
+// Unlike aside, it implements Block without embedding BaseBlock.
+type box struct {
+	children []Block
+}
+
+func (b *box) Children() []Block            { return b.children }
+func (b *box) AppendChild(child Block)      { b.children = append(b.children, child) }
+func (b *box) ReplaceLastChild(child Block) { b.children[len(b.children)-1] = child }
+func (b *box) SetChildren(children []Block) { b.children = children }
+func (b *box) AppendLine([]byte)            {}
+func (b *box) AcceptsLines() bool           { return false }
+func (b *box) AcceptsLiteralLines() bool    { return false }
+func (b *box) CanContain(Block) bool        { return true }
+
+type boxParser struct{}
+
+func (boxParser) Start(s BlockParserState, line []byte) ([]byte, bool) {
+	if !bytes.HasPrefix(line, []byte("; ")) {
+		return nil, false
+	}
+	s.AddBlock(&box{}, boxParser{})
+	return line[2:], true
+}
+
+func (boxParser) Continue(b Block, line []byte) ([]byte, bool) {
+	if !bytes.HasPrefix(line, []byte("; ")) {
+		return nil, false
+	}
+	return line[2:], true
+}
+
+func (boxParser) Close(b Block) {}
+
+func renderBox(r *HTMLRenderer, b Block, out io.Writer) {
+	io.WriteString(out, "<div>\n")
+	r.RenderChildren(b, out)
+	io.WriteString(out, "</div>\n")
+}
+
+// comment is a custom leaf block: a line starting with %%, which is dropped
+// from the output.
+type comment struct {
+	BaseBlock
+}
+
+type commentParser struct {
+	leafBlockParser
+	closed *int
+}
+
+func (p commentParser) Start(s BlockParserState, line []byte) ([]byte, bool) {
+	if !bytes.HasPrefix(line, []byte("%%")) {
+		return nil, false
+	}
+	s.AddBlock(&comment{}, p)
+	return nil, true
+}
+
+func (p commentParser) Close(b Block) {
+	*p.closed++
+}
+
+func TestBlockParsers(t *testing.T) {
+	var closed int
+	converter := New(
+		WithBlockParsers(asideParser{}, commentParser{closed: &closed}),
+		WithBlockRenderFunc(&aside{}, renderAside),
+		WithBlockRenderFunc(&comment{}, func(r *HTMLRenderer, b Block, out io.Writer) {}),
+	)
+	runHTMLTests(t, converter, []htmlTest{
+		{"| # Note\n| text\n| > quote\n# after\n", "<aside>\n<h1>Note</h1>\n<p>text</p>\n<blockquote>\n<p>quote</p>\n</blockquote>\n</aside>\n<h1>after</h1>\n"},
+		{"| > quote\nlazy\n", "<aside>\n<blockquote>\n<p>quote\nlazy</p>\n</blockquote>\n</aside>\n"},
+		{"para\n%% hidden\n| %% hidden\n", "<p>para</p>\n<aside>\n</aside>\n"},
+		{"    | code\n", "<pre><code>| code\n</code></pre>\n"},
+	})
+	if closed != 2 {
+		t.Errorf("expected 2 comment blocks to be closed, got %d", closed)
+	}
+}
+
+func TestLists(t *testing.T) {
+	runHTMLTests(t, New(), []htmlTest{
+		{"- one\n\n two\n", "<ul>\n<li>one</li>\n</ul>\n<p>two</p>\n"},
+		{"- one\n\n  two\n", "<ul>\n<li><p>one</p>\n<p>two</p></li>\n</ul>\n"},
+		{"- foo\n- bar\n+ baz\n", "<ul>\n<li>foo</li>\n<li>bar</li>\n</ul>\n<ul>\n<li>baz</li>\n</ul>\n"},
+		{"1. foo\n2. bar\n3) baz\n", "<ol>\n<li>foo</li>\n<li>bar</li>\n</ol>\n<ol start=\"3\">\n<li>baz</li>\n</ol>\n"},
+		{"Foo\n- bar\n- baz\n", "<p>Foo</p>\n<ul>\n<li>bar</li>\n<li>baz</li>\n</ul>\n"},
+		{"The number of windows in my house is\n14.  The number of doors is 6.\n", "<p>The number of windows in my house is</p>\n<ol start=\"14\">\n<li>The number of doors is 6.</li>\n</ol>\n"},
+		{"- a\n- b\n\n- c\n", "<ul>\n<li><p>a</p></li>\n<li><p>b</p></li>\n<li><p>c</p></li>\n</ul>\n"},
+		{"- a\n  - b\n    - c\n", "<ul>\n<li>a\n<ul>\n<li>b\n<ul>\n<li>c</li>\n</ul></li>\n</ul></li>\n</ul>\n"},
+		{"- a\n> b\n", "<ul>\n<li>a</li>\n</ul>\n<blockquote>\n<p>b</p>\n</blockquote>\n"},
+		{"- foo\nbar\n", "<ul>\n<li>foo\nbar</li>\n</ul>\n"},
+	})
+}
+
+func TestFencedCodeBlocks(t *testing.T) {
+	runHTMLTests(t, New(), []htmlTest{
+		{"```\n<\n >\n```\n", "<pre><code>&lt;\n &gt;\n</code></pre>\n"},
+		{"```\naaa\n~~~\n```\n", "<pre><code>aaa\n~~~\n</code></pre>\n"},
+		{"````\naaa\n```\n``````\n", "<pre><code>aaa\n```\n</code></pre>\n"},
+		{"`````\n\n```\naaa\n", "<pre><code>\n```\naaa\n</code></pre>\n"},
+		{"  ```\naaa\n  aaa\naaa\n  ```\n", "<pre><code>aaa\naaa\naaa\n</code></pre>\n"},
+		{"    ```\n    aaa\n    ```\n", "<pre><code>```\naaa\n```\n</code></pre>\n"},
+		{"```\naaa\n    ```\n", "<pre><code>aaa\n    ```\n</code></pre>\n"},
+		{"foo\n```\nbar\n```\nbaz\n", "<p>foo</p>\n<pre><code>bar\n</code></pre>\n<p>baz</p>\n"},
+		{"```ruby\ndef foo(x)\n  return 3\nend\n```\n", "<pre><code class=\"language-ruby\">def foo(x)\n  return 3\nend\n</code></pre>\n"},
+		{"~~~~    ruby startline=3 $%@#$\ndef foo(x)\n~~~~~~~\n", "<pre><code class=\"language-ruby\">def foo(x)\n</code></pre>\n"},
+		{"````;\n````\n", "<pre><code class=\"language-;\"></code></pre>\n"},
+		{"```a\\_b&amp;c\n```\n", "<pre><code class=\"language-a_b&amp;c\"></code></pre>\n"},
+		{"- ```\n  code\n  ```\n", "<ul>\n<li><pre><code>code\n</code></pre></li>\n</ul>\n"},
+	})
+}
+
+// caption is a custom leaf block with inline content, marked by "^ " at the
+// start of a single line. Like box, it implements Block without embedding
+// BaseBlock.
+type caption struct {
+	content []byte
+	inlines Inline
+}
+
+func (c *caption) Children() []Block           { return nil }
+func (c *caption) AppendChild(Block)           {}
+func (c *caption) ReplaceLastChild(Block)      {}
+func (c *caption) SetChildren([]Block)         {}
+func (c *caption) AppendLine(line []byte)      { c.content = append(c.content, line...) }
+func (c *caption) AcceptsLines() bool          { return true }
+func (c *caption) AcceptsLiteralLines() bool   { return false }
+func (c *caption) CanContain(Block) bool       { return false }
+func (c *caption) RawInlineContent() []byte    { return bytes.TrimSpace(c.content) }
+func (c *caption) SetInlineContent(i Inline)   { c.inlines = i }
+func (c *caption) ParsedInlineContent() Inline { return c.inlines }
+
+func renderCaption(r *HTMLRenderer, b Block, out io.Writer) {
+	io.WriteString(out, "<figcaption>")
+	r.RenderInline(b.(*caption).inlines, out)
+	io.WriteString(out, "</figcaption>\n")
+}
+
+type captionParser struct{}
+
+func (captionParser) Start(s BlockParserState, line []byte) ([]byte, bool) {
+	if !bytes.HasPrefix(line, []byte("^ ")) {
+		return nil, false
+	}
+	s.AddBlock(&caption{}, captionParser{})
+	return line[2:], true
+}
+
+func (captionParser) Continue(b Block, line []byte) ([]byte, bool) {
+	return nil, false
+}
+
+func (captionParser) Close(b Block) {}
+
+func TestInlineContainers(t *testing.T) {
+	converter := New(
+		WithBlockParsers(captionParser{}),
+		WithBlockRenderFunc(&caption{}, renderCaption),
+	)
+	runHTMLTests(t, converter, []htmlTest{
+		{"^ a `b` &amp; [c](/d)\n", "<figcaption>a <code>b</code> &amp; <a href=\"/d\">c</a></figcaption>\n"},
+		{"para\n^ cap\n", "<p>para</p>\n<figcaption>cap</figcaption>\n"},
+	})
+}
@@ -4,8 +4,185 @@ package commonmark
 
 import (
 	"bytes"
+	"io"
 )
 
+// Converter converts CommonMark to HTML according to a set of options. Create
+// one using New. A Converter can be reused, also from multiple goroutines.
+type Converter struct {
+	parseOptions parseOptions
+	renderer     *HTMLRenderer
+	extensions   []Extension
+}
+
+// parseOptions controls how CommonMark is parsed.
+type parseOptions struct {
+	// blockParsers holds all BlockParsers, including the built-in ones,
+	// sorted by priority.
+	blockParsers []prioritizedBlockParser
+	// inlineParsers maps trigger characters to the InlineParsers that are
+	// tried, sorted by priority, before any built-in parsing of that
+	// character.
+	inlineParsers map[byte][]prioritizedInlineParser
+	// transforms holds the Transforms to run at each phase, sorted by
+	// priority.
+	transforms map[TransformPhase][]prioritizedTransform
+	// delimiterRules maps characters to the rules for constructs that are
+	// delimited by runs of that character.
+	delimiterRules map[byte][]*DelimiterRule
+	// smart enables smart punctuation.
+	smart bool
+	// attributes enables attribute blocks after links and images; see
+	// Attributes.
+	attributes bool
+	// frontMatter enables front matter, which is decoded by
+	// frontMatterDecoder if it is not nil; see WithFrontMatter.
+	frontMatter        bool
+	frontMatterDecoder FrontMatterDecoder
+}
+
+// Option configures a Converter. Options are passed to New.
+type Option func(*Converter)
+
+// New returns a Converter configured by the given options. Without any
+// options, it behaves exactly like the package-level ToHTMLBytes function.
+func New(options ...Option) *Converter {
+	c := &Converter{
+		parseOptions: parseOptions{
+			blockParsers: defaultBlockParsers(),
+		},
+		renderer: NewHTMLRenderer(),
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// WithSmartPunctuation makes the Converter replace straight quotes by curly
+// quotes, -- and --- by en and em dashes, and ... by an ellipsis. Quotes are
+// paired up using the same rules that determine whether emphasis delimiters
+// can open or close; unpaired single quotes become apostrophes. Code spans,
+// code blocks, raw HTML and backslash-escaped characters are left alone.
+func WithSmartPunctuation() Option {
+	return func(c *Converter) {
+		c.parseOptions.smart = true
+	}
+}
+
+// WithBlockParsers makes the Converter recognize custom types of blocks. The
+// parsers are tried in the given order, before any of the built-in ones, so
+// they can also be used to override the parsing of built-in block types. Use
+// Converter.AddBlockParser for finer control over the order.
+func WithBlockParsers(parsers ...BlockParser) Option {
+	return func(c *Converter) {
+		for _, parser := range parsers {
+			c.AddBlockParser(parser, 0)
+		}
+	}
+}
+
+// WithInlineParser makes the Converter recognize a custom type of inline
+// content, which starts with the given trigger character. Multiple parsers can
+// be registered for the same character; they are tried in the order they were
+// registered, before any built-in parsing of that character.
+func WithInlineParser(trigger byte, parser InlineParser) Option {
+	return func(c *Converter) {
+		c.AddInlineParser(trigger, parser, 0)
+	}
+}
+
+// WithDelimiterRule makes the Converter recognize a custom construct that is
+// delimited by runs of the same character; see DelimiterRule.
+func WithDelimiterRule(rule DelimiterRule) Option {
+	return func(c *Converter) {
+		c.AddDelimiterRule(rule)
+	}
+}
+
+// WithTransform makes the Converter run the given Transform on the parse tree
+// at the given phase. Transforms are run in the order in which they were
+// given; use Converter.AddTransform for finer control over the order.
+func WithTransform(phase TransformPhase, transform Transform) Option {
+	return func(c *Converter) {
+		c.AddTransform(phase, transform, 0)
+	}
+}
+
+// WithHTMLPolicy makes the Converter pass all raw HTML (both HTML blocks and
+// inline tags) through the given policy. Tags and attributes that the policy
+// rejects are escaped, so they show up as text rather than being interpreted
+// by the browser.
+func WithHTMLPolicy(policy *HTMLPolicy) Option {
+	return func(c *Converter) {
+		c.renderer.SetHTMLPolicy(policy)
+	}
+}
+
+// WithTagFilter makes the Converter escape the tags that GitHub Flavored
+// Markdown disallows in raw HTML, in both HTML blocks and inline HTML, like
+// GitHub does: https://github.github.com/gfm/#disallowed-raw-html-extension-
+//
+// Unlike an HTMLPolicy, it leaves all other HTML alone. If both are used, the
+// tag filter is applied first.
+func WithTagFilter() Option {
+	return func(c *Converter) {
+		c.renderer.SetTagFilter(true)
+	}
+}
+
+// WithHTMLFlavor selects the syntax of the HTML output; the default is XHTML.
+func WithHTMLFlavor(flavor HTMLFlavor) Option {
+	return func(c *Converter) {
+		c.renderer.SetHTMLFlavor(flavor)
+	}
+}
+
+// WithAttributeQuote selects the quote character written around attribute
+// values; the default is DoubleQuotes.
+func WithAttributeQuote(quote AttributeQuote) Option {
+	return func(c *Converter) {
+		c.renderer.SetAttributeQuote(quote)
+	}
+}
+
+// WithHardWraps makes the Converter render every soft line break (a line
+// break in a paragraph that is not preceded by two spaces or a backslash) as
+// a hard line break, so newlines in the input show up as newlines in the
+// browser.
+func WithHardWraps() Option {
+	return func(c *Converter) {
+		c.renderer.SetHardWraps(true)
+	}
+}
+
+// WithSoftBreak sets the string that soft line breaks are rendered as; the
+// default is "\n". For example, use " " to put each paragraph on a single
+// line. It has no effect if WithHardWraps is also given.
+func WithSoftBreak(softBreak string) Option {
+	return func(c *Converter) {
+		c.renderer.SetSoftBreak(softBreak)
+	}
+}
+
+// WithBlockRenderFunc makes the Converter render blocks of the same type as b
+// using the given function. This can be used to customize the HTML for a
+// built-in type, or to render a custom Block type. See
+// HTMLRenderer.SetBlockFunc.
+func WithBlockRenderFunc(b Block, f BlockRenderFunc) Option {
+	return func(c *Converter) {
+		c.renderer.SetBlockFunc(b, f)
+	}
+}
+
+// WithInlineRenderFunc makes the Converter render inlines of the same type as
+// i using the given function. See HTMLRenderer.SetInlineFunc.
+func WithInlineRenderFunc(i Inline, f InlineRenderFunc) Option {
+	return func(c *Converter) {
+		c.renderer.SetInlineFunc(i, f)
+	}
+}
+
 // ToHTMLBytes converts text formatted in CommonMark into the corresponding
 // HTML.
 //
@@ -15,20 +192,41 @@ import (
 // endings in the input (which can be CR, LF or CRLF).
 //
 // Note that the output might contain unsafe tags (e.g. <script>); if you are
-// accepting untrusted user input, you must run the output through a sanitizer
-// before sending it to a browser.
+// accepting untrusted user input, you must either run the output through a
+// sanitizer before sending it to a browser, or use a Converter with an
+// HTMLPolicy (see WithHTMLPolicy).
 func ToHTMLBytes(data []byte) ([]byte, error) {
-	doc, err := parse(data)
+	return New().ToHTMLBytes(data)
+}
+
+// ToHTMLBytes converts text formatted in CommonMark into the corresponding
+// HTML, using the options that the Converter was created with. See the
+// package-level ToHTMLBytes function for details.
+func (c *Converter) ToHTMLBytes(data []byte) ([]byte, error) {
+	doc, err := c.Parse(data)
 	if err != nil {
 		return nil, err
 	}
 
 	var buffer bytes.Buffer
-	blockToHTML(doc, &buffer)
+	c.Render(doc, &buffer)
 	return buffer.Bytes(), nil
 }
 
-func parse(data []byte) (*document, error) {
+// Parse parses text formatted in CommonMark into a parse tree, using the
+// options that the Converter was created with, including running its
+// Transforms. The tree can be inspected and modified, and then rendered using
+// Render.
+func (c *Converter) Parse(data []byte) (*Document, error) {
+	return parse(data, &c.parseOptions)
+}
+
+// Render writes the HTML for a parse tree, as returned by Parse.
+func (c *Converter) Render(doc *Document, out io.Writer) {
+	c.renderer.RenderBlock(doc, out)
+}
+
+func parse(data []byte, options *parseOptions) (*Document, error) {
 	// See http://spec.commonmark.org/0.7/#appendix-a-a-parsing-strategy
 	// "Parsing has two phases:"
 
@@ -37,31 +235,44 @@ func parse(data []byte) (*document, error) {
 	// and so on—is constructed. Text is assigned to these blocks but not
 	// parsed. Link reference definitions are parsed and a map of links is
 	// constructed."
-	doc, err := parseBlocks(data)
+	var frontMatter *FrontMatter
+	if options.frontMatter {
+		frontMatter, data = extractFrontMatter(data)
+	}
+	doc, err := parseBlocks(data, options)
 	if err != nil {
 		return nil, err
 	}
+	if frontMatter != nil {
+		doc.FrontMatter = frontMatter
+		if options.frontMatterDecoder != nil {
+			if frontMatter.Data, err = options.frontMatterDecoder(frontMatter.Format, frontMatter.Raw); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := runTransforms(doc, options.transforms[AfterBlockParsing]); err != nil {
+		return nil, err
+	}
 
 	// "In the second phase, the raw text contents of paragraphs and headers
 	// are parsed into sequences of Markdown inline elements (strings, code
 	// spans, links, emphasis, and so on), using the map of link references
 	// constructed in phase 1."
-	processInlines(doc)
+	processInlines(doc, options)
+	if err := runTransforms(doc, options.transforms[AfterInlineParsing]); err != nil {
+		return nil, err
+	}
 
 	return doc, nil
 }
 
-func processInlines(b Block) {
-	switch t := b.(type) {
-	case *atxHeader:
-		t.inlineContent = parseInlines(t.content)
-	case *paragraph:
-		// "Final spaces are stripped before inline parsing, so a paragraph that
-		// ends with two or more spaces will not end with a hard line break."
-		t.inlineContent = parseInlines(bytes.TrimRight(t.content, " "))
+func processInlines(b Block, options *parseOptions) {
+	if c, ok := b.(InlineContainer); ok {
+		c.SetInlineContent(parseInlines(c.RawInlineContent(), options))
 	}
 
 	for _, child := range b.Children() {
-		processInlines(child)
+		processInlines(child, options)
 	}
 }
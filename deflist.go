@@ -0,0 +1,200 @@
+package commonmark
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+)
+
+// DefinitionLists is an Extension that adds support for definition lists, in
+// the syntax of PHP Markdown Extra:
+// https://michelf.ca/projects/php-markdown/extra/#def-list
+//
+// Each line of a paragraph that is followed by a definition is a term. A
+// definition starts with a colon, indented by at most three spaces and
+// followed by one or more spaces, and can contain any blocks that are
+// indented as far as its first line. A term can have several definitions. If
+// a definition is preceded by a blank line, its paragraphs are wrapped in <p>
+// tags.
+var DefinitionLists Extension = definitionListsExtension{}
+
+type definitionListsExtension struct{}
+
+func (definitionListsExtension) Extend(c *Converter) {
+	c.AddBlockParser(definitionParser{}, 750)
+	c.AddTransform(AfterBlockParsing, groupDefinitions, 0)
+	c.Renderer().SetBlockFunc(&DefinitionList{}, renderDefinitionList)
+	c.Renderer().SetBlockFunc(&DefinitionTerm{}, renderDefinitionTerm)
+	c.Renderer().SetBlockFunc(&Definition{}, renderDefinition)
+}
+
+// DefinitionList is a definition list. Its children are DefinitionTerms, each
+// followed by one or more Definitions.
+type DefinitionList struct {
+	BaseBlock
+}
+
+// DefinitionTerm is a term in a definition list, which contains inline
+// content.
+type DefinitionTerm struct {
+	BaseBlock
+}
+
+func (t *DefinitionTerm) RawInlineContent() []byte {
+	return t.Content
+}
+
+func (t *DefinitionTerm) SetInlineContent(inline Inline) {
+	t.InlineContent = inline
+}
+
+func (t *DefinitionTerm) ParsedInlineContent() Inline {
+	return t.InlineContent
+}
+
+// Definition is the definition of the preceding term in a definition list,
+// which can contain any blocks.
+type Definition struct {
+	BaseBlock
+	// Loose is true if the definition is preceded by a blank line, or
+	// contains blocks separated by blank lines. Its paragraphs are then
+	// rendered with <p> tags.
+	Loose bool
+
+	// contentOffset is the indentation that continuation lines need to be
+	// part of the definition.
+	contentOffset int
+	blanks        blankTracker
+}
+
+func (d *Definition) CanContain(Block) bool {
+	return true
+}
+
+var definitionMarkerRe = regexp.MustCompile(`^ {0,3}:[ \n]`)
+
+type definitionParser struct{}
+
+// Start starts a Definition after a paragraph, which holds its terms, or after
+// another Definition. It is grouped into a DefinitionList later, by
+// groupDefinitions.
+func (definitionParser) Start(s BlockParserState, line []byte) ([]byte, bool) {
+	m := definitionMarkerRe.FindIndex(line)
+	if m == nil {
+		return nil, false
+	}
+	var loose bool
+	if _, isParagraph := s.OpenBlock().(*Paragraph); !isParagraph {
+		// A closed paragraph before it must have been closed by a blank
+		// line; a definition may have been closed by this line.
+		switch last := lastBlock(s.OpenBlock().Children()).(type) {
+		case *Paragraph:
+			loose = true
+		case *Definition:
+			loose = last.blanks.afterBlank
+		default:
+			return nil, false
+		}
+	}
+
+	// Like in list items, the content starts after the marker and up to four
+	// spaces, unless it starts with indented code or is empty.
+	markerEnd := m[1] - 1
+	contentOffset := markerEnd + 1
+	if spaces := indentation(line[markerEnd:]); spaces < 5 && line[markerEnd+spaces] != '\n' {
+		contentOffset = markerEnd + spaces
+	}
+	s.AddBlock(&Definition{Loose: loose, contentOffset: contentOffset}, definitionParser{})
+	if contentOffset >= len(line) {
+		return nil, true
+	}
+	return line[contentOffset:], true
+}
+
+// Continue continues the definition on blank lines and on lines that are
+// indented at least as far as its content.
+func (definitionParser) Continue(b Block, line []byte) ([]byte, bool) {
+	def := b.(*Definition)
+	if isBlank(line) {
+		def.blanks.line(def, line)
+		if len(line) > def.contentOffset {
+			return line[def.contentOffset:], true
+		}
+		return line[len(line)-1:], true
+	}
+	if indentation(line) < def.contentOffset {
+		return nil, false
+	}
+	def.blanks.line(def, line)
+	return line[def.contentOffset:], true
+}
+
+func (definitionParser) Close(b Block) {
+	def := b.(*Definition)
+	def.blanks.line(def, nil)
+	def.Loose = def.Loose || def.blanks.loose
+}
+
+// groupDefinitions is a Transform that turns each paragraph followed by
+// Definitions into DefinitionTerms, and groups them into a DefinitionList.
+// Consecutive groups of terms and definitions form a single list.
+func groupDefinitions(doc *Document) error {
+	walkBlocks(doc, func(b Block) {
+		var children []Block
+		for _, child := range b.Children() {
+			def, isDefinition := child.(*Definition)
+			if !isDefinition || len(children) == 0 {
+				children = append(children, child)
+				continue
+			}
+			last := children[len(children)-1]
+			if par, isParagraph := last.(*Paragraph); isParagraph {
+				children = children[:len(children)-1]
+				list, isList := lastBlock(children).(*DefinitionList)
+				if !isList {
+					list = &DefinitionList{}
+					children = append(children, list)
+				}
+				for _, term := range bytes.SplitAfter(par.Content, []byte("\n")) {
+					if len(term) > 0 {
+						list.AppendChild(&DefinitionTerm{BaseBlock{Content: term}})
+					}
+				}
+				list.AppendChild(def)
+			} else if list, isList := last.(*DefinitionList); isList {
+				list.AppendChild(def)
+			} else {
+				children = append(children, child)
+			}
+		}
+		b.SetChildren(children)
+	})
+	return nil
+}
+
+// lastBlock returns the last of the blocks, or nil if there are none.
+func lastBlock(blocks []Block) Block {
+	if len(blocks) == 0 {
+		return nil
+	}
+	return blocks[len(blocks)-1]
+}
+
+func renderDefinitionList(r *HTMLRenderer, b Block, out io.Writer) {
+	io.WriteString(out, "<dl>\n")
+	r.RenderChildren(b, out)
+	io.WriteString(out, "</dl>\n")
+}
+
+func renderDefinitionTerm(r *HTMLRenderer, b Block, out io.Writer) {
+	io.WriteString(out, "<dt>")
+	r.RenderInline(b.(*DefinitionTerm).InlineContent, out)
+	io.WriteString(out, "</dt>\n")
+}
+
+func renderDefinition(r *HTMLRenderer, b Block, out io.Writer) {
+	def := b.(*Definition)
+	io.WriteString(out, "<dd>")
+	r.renderItemChildren(def, !def.Loose, out)
+	io.WriteString(out, "</dd>\n")
+}
@@ -0,0 +1,33 @@
+package commonmark
+
+import (
+	"testing"
+)
+
+func TestDefinitionLists(t *testing.T) {
+	runHTMLTests(t, New(WithExtensions(DefinitionLists)), []htmlTest{
+		{"Apple\n:   Pomaceous fruit of plants of the genus Malus in\n    the family Rosaceae.\n\nOrange\n:   The fruit of an evergreen tree of the genus Citrus.\n",
+			"<dl>\n<dt>Apple</dt>\n<dd>Pomaceous fruit of plants of the genus Malus in\nthe family Rosaceae.</dd>\n<dt>Orange</dt>\n<dd>The fruit of an evergreen tree of the genus Citrus.</dd>\n</dl>\n"},
+		{"Term 1\nTerm *2*\n: First\n: `Second`\n",
+			"<dl>\n<dt>Term 1</dt>\n<dt>Term *2*</dt>\n<dd>First</dd>\n<dd><code>Second</code></dd>\n</dl>\n"},
+		{"Apple\n\n:   Pomaceous fruit.\n\n    Also a company.\n\n:   Second.\nlazy\n",
+			"<dl>\n<dt>Apple</dt>\n<dd><p>Pomaceous fruit.</p>\n<p>Also a company.</p></dd>\n<dd><p>Second.\nlazy</p></dd>\n</dl>\n"},
+		{"Term\n:   > quote\n\n        code\n    - item\n",
+			"<dl>\n<dt>Term</dt>\n<dd><blockquote>\n<p>quote</p>\n</blockquote>\n<pre><code>code\n</code></pre>\n<ul>\n<li>item</li>\n</ul></dd>\n</dl>\n"},
+		{"> Term\n> : Definition\n\nafter\n",
+			"<blockquote>\n<dl>\n<dt>Term</dt>\n<dd>Definition</dd>\n</dl>\n</blockquote>\n<p>after</p>\n"},
+		{": no term\n\n# Header\n: no term\n\nratio 1:2\n",
+			"<p>: no term</p>\n<h1>Header</h1>\n<p>: no term</p>\n<p>ratio 1:2</p>\n"},
+	})
+	converter := New(
+		WithExtensions(DefinitionLists),
+		WithBlockParsers(boxParser{}),
+		WithBlockRenderFunc(&box{}, renderBox),
+	)
+	runHTMLTests(t, converter, []htmlTest{
+		{"; text\n\nTerm\n: Definition\n",
+			"<div>\n<p>text</p>\n</div>\n<dl>\n<dt>Term</dt>\n<dd>Definition</dd>\n</dl>\n"},
+		{"; Term\n; : Definition\n",
+			"<div>\n<dl>\n<dt>Term</dt>\n<dd>Definition</dd>\n</dl>\n</div>\n"},
+	})
+}
@@ -0,0 +1,92 @@
+package commonmark
+
+import (
+	"io"
+	"regexp"
+)
+
+// Emoji is an Extension that replaces shortcodes like :smile: by emoji. It
+// knows a subset of the shortcodes of GitHub's gemoji project, listed in
+// scripts/emoji_subset.json; use EmojiWithMap to add others. Shortcodes that
+// are not known are left alone as literal text.
+var Emoji Extension = &emojiExtension{}
+
+// EmojiWithMap returns an Extension like Emoji, which also recognizes the
+// shortcodes in the given map, taking precedence over the built-in ones. The
+// map takes the names of shortcodes, without colons, to the text to replace
+// them by. The text may be empty for custom emoji that have no Unicode
+// equivalent; they are rendered as their shortcode, unless a render function
+// like the one returned by EmojiImageRenderFunc is set for EmojiInline.
+func EmojiWithMap(custom map[string]string) Extension {
+	return &emojiExtension{custom}
+}
+
+// emojiExtension is used through a pointer, because it is not comparable.
+type emojiExtension struct {
+	custom map[string]string
+}
+
+func (e *emojiExtension) Extend(c *Converter) {
+	c.AddInlineParser(':', emojiParser{e.custom}, 0)
+	c.Renderer().SetInlineFunc(&EmojiInline{}, renderEmojiInline)
+}
+
+// EmojiInline is an emoji that was given by its shortcode.
+type EmojiInline struct {
+	// Name is the name of the shortcode, without colons, like smile.
+	Name string
+	// Text is the emoji itself, or empty for custom emoji without Unicode
+	// equivalent.
+	Text string
+}
+
+var emojiShortcodeRe = regexp.MustCompile(`^:([A-Za-z0-9_+-]+):`)
+
+type emojiParser struct {
+	custom map[string]string
+}
+
+func (p emojiParser) Parse(s InlineParserState) (Inline, int) {
+	m := emojiShortcodeRe.FindSubmatch(s.Data()[s.Pos():])
+	if m == nil {
+		return nil, 0
+	}
+	name := string(m[1])
+	text, ok := p.custom[name]
+	if !ok {
+		text, ok = emojiShortcodes[name]
+	}
+	if !ok {
+		return nil, 0
+	}
+	return &EmojiInline{Name: name, Text: text}, len(m[0])
+}
+
+func renderEmojiInline(r *HTMLRenderer, i Inline, out io.Writer) {
+	emoji := i.(*EmojiInline)
+	if emoji.Text == "" {
+		WriteEscaped([]byte(":"+emoji.Name+":"), out)
+		return
+	}
+	WriteEscaped([]byte(emoji.Text), out)
+}
+
+// EmojiImageRenderFunc returns an InlineRenderFunc for EmojiInline that
+// renders emoji as images, like <img class="emoji" src="…" alt=":smile:" />.
+// The url function returns the URL of the image for the name of a shortcode;
+// if it returns an empty string, the emoji is rendered as text instead.
+func EmojiImageRenderFunc(url func(name string) string) InlineRenderFunc {
+	return func(r *HTMLRenderer, i Inline, out io.Writer) {
+		emoji := i.(*EmojiInline)
+		src := url(emoji.Name)
+		if src == "" {
+			renderEmojiInline(r, i, out)
+			return
+		}
+		io.WriteString(out, "<img")
+		r.WriteAttr("class", []byte("emoji"), out)
+		r.WriteAttr("src", EscapeURL([]byte(src)), out)
+		r.WriteAttr("alt", []byte(":"+emoji.Name+":"), out)
+		r.EndVoidTag(out)
+	}
+}
@@ -0,0 +1,122 @@
+package commonmark
+
+// Autogenerated by scripts/generate_emoji_shortcodes_go.py scripts/emoji_subset.json; do not edit.
+var emojiShortcodes = map[string]string{
+	"+1":                       "👍",
+	"-1":                       "👎",
+	"100":                      "💯",
+	"angry":                    "😠",
+	"apple":                    "🍎",
+	"arrow_down":               "⬇️",
+	"arrow_left":               "⬅️",
+	"arrow_right":              "➡️",
+	"arrow_up":                 "⬆️",
+	"art":                      "🎨",
+	"beer":                     "🍺",
+	"bell":                     "🔔",
+	"blush":                    "😊",
+	"book":                     "📖",
+	"boom":                     "💥",
+	"broken_heart":             "💔",
+	"bug":                      "🐛",
+	"bulb":                     "💡",
+	"cake":                     "🍰",
+	"calendar":                 "📆",
+	"cat":                      "🐱",
+	"chart_with_upwards_trend": "📈",
+	"checkered_flag":           "🏁",
+	"clap":                     "👏",
+	"cloud":                    "☁️",
+	"coffee":                   "☕",
+	"collision":                "💥",
+	"computer":                 "💻",
+	"confused":                 "😕",
+	"construction":             "🚧",
+	"cry":                      "😢",
+	"dog":                      "🐶",
+	"e-mail":                   "📧",
+	"earth_americas":           "🌎",
+	"email":                    "📧",
+	"exclamation":              "❗",
+	"eyes":                     "👀",
+	"fire":                     "🔥",
+	"gear":                     "⚙️",
+	"ghost":                    "👻",
+	"grin":                     "😁",
+	"grinning":                 "😀",
+	"hammer":                   "🔨",
+	"hankey":                   "💩",
+	"heart":                    "❤️",
+	"heart_eyes":               "😍",
+	"heavy_check_mark":         "✔️",
+	"heavy_exclamation_mark":   "❗",
+	"heavy_minus_sign":         "➖",
+	"heavy_plus_sign":          "➕",
+	"hourglass":                "⌛",
+	"innocent":                 "😇",
+	"iphone":                   "📱",
+	"joy":                      "😂",
+	"key":                      "🔑",
+	"kissing_heart":            "😘",
+	"laughing":                 "😆",
+	"link":                     "🔗",
+	"lock":                     "🔒",
+	"mag":                      "🔍",
+	"memo":                     "📝",
+	"muscle":                   "💪",
+	"neutral_face":             "😐",
+	"ok_hand":                  "👌",
+	"open_book":                "📖",
+	"package":                  "📦",
+	"pencil":                   "📝",
+	"penguin":                  "🐧",
+	"pizza":                    "🍕",
+	"point_left":               "👈",
+	"point_right":              "👉",
+	"point_up":                 "☝️",
+	"poop":                     "💩",
+	"pout":                     "😡",
+	"pray":                     "🙏",
+	"question":                 "❓",
+	"rage":                     "😡",
+	"rainbow":                  "🌈",
+	"raised_hands":             "🙌",
+	"recycle":                  "♻️",
+	"relaxed":                  "☺️",
+	"robot":                    "🤖",
+	"rocket":                   "🚀",
+	"rofl":                     "🤣",
+	"satisfied":                "😆",
+	"scream":                   "😱",
+	"see_no_evil":              "🙈",
+	"shit":                     "💩",
+	"skull":                    "💀",
+	"slightly_smiling_face":    "🙂",
+	"smile":                    "😄",
+	"smiley":                   "😃",
+	"smirk":                    "😏",
+	"snake":                    "🐍",
+	"snowflake":                "❄️",
+	"sob":                      "😭",
+	"sparkles":                 "✨",
+	"star":                     "⭐",
+	"stuck_out_tongue":         "😛",
+	"sunglasses":               "😎",
+	"sunny":                    "☀️",
+	"sweat_smile":              "😅",
+	"tada":                     "🎉",
+	"thinking":                 "🤔",
+	"thumbsdown":               "👎",
+	"thumbsup":                 "👍",
+	"trophy":                   "🏆",
+	"umbrella":                 "☔",
+	"unlock":                   "🔓",
+	"upside_down_face":         "🙃",
+	"warning":                  "⚠️",
+	"wave":                     "👋",
+	"white_check_mark":         "✅",
+	"wink":                     "😉",
+	"wrench":                   "🔧",
+	"x":                        "❌",
+	"zap":                      "⚡",
+}
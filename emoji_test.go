@@ -0,0 +1,33 @@
+package commonmark
+
+import (
+	"testing"
+)
+
+func TestEmoji(t *testing.T) {
+	runHTMLTests(t, New(WithExtensions(Emoji)), []htmlTest{
+		{":smile: :+1::tada:\n", "<p>😄 👍🎉</p>\n"},
+		{"Ship it :rocket:!\n", "<p>Ship it 🚀!</p>\n"},
+		{":not_an_emoji: :smile :: 10:30:45\n", "<p>:not_an_emoji: :smile :: 10:30:45</p>\n"},
+		{"`:smile:` \\:smile:\n", "<p><code>:smile:</code> :smile:</p>\n"},
+		{":sweat_smile:smile:\n", "<p>😅smile:</p>\n"},
+	})
+	runHTMLTests(t, New(WithExtensions(EmojiWithMap(map[string]string{"smile": "🙂", "party": "🥳", "shipit": ""}))), []htmlTest{
+		{":smile: :party: :tada: :shipit:\n", "<p>🙂 🥳 🎉 :shipit:</p>\n"},
+	})
+	converter := New(
+		WithExtensions(EmojiWithMap(map[string]string{"shipit": ""})),
+		WithInlineRenderFunc(&EmojiInline{}, EmojiImageRenderFunc(func(name string) string {
+			if name == "shipit" {
+				return "/emoji/ship it.png"
+			}
+			return ""
+		})),
+	)
+	runHTMLTests(t, converter, []htmlTest{
+		{":shipit: :smile:\n", "<p><img class=\"emoji\" src=\"/emoji/ship%20it.png\" alt=\":shipit:\" /> 😄</p>\n"},
+	})
+	runHTMLTests(t, New(), []htmlTest{
+		{":smile:\n", "<p>:smile:</p>\n"},
+	})
+}
@@ -0,0 +1,153 @@
+package commonmark
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Extension adds a feature to a Converter, typically by registering several
+// parsers and render functions that work together, like a BlockParser for a
+// new type of Block and the BlockRenderFunc that renders it.
+type Extension interface {
+	// Extend registers the extension on the given Converter, using its Add*
+	// methods, its Renderer, or by applying Options to it. This way, an
+	// extension can combine parsers, render functions and Transforms.
+	Extend(c *Converter)
+}
+
+// WithExtensions enables the given extensions on the Converter, in the given
+// order. An extension that has already been enabled, for example as part of
+// another extension, is skipped.
+//
+// The order in which extensions are enabled matters only where they conflict:
+// between parsers, priorities are decisive, and parsers with equal priority
+// are tried in the order in which they were added; between render functions
+// for the same type, the last one wins.
+func WithExtensions(extensions ...Extension) Option {
+	return func(c *Converter) {
+		for _, extension := range extensions {
+			if c.hasExtension(extension) {
+				continue
+			}
+			c.extensions = append(c.extensions, extension)
+			extension.Extend(c)
+		}
+	}
+}
+
+// hasExtension returns whether the given extension has been enabled already.
+// Extensions of types that cannot be compared are never considered equal.
+func (c *Converter) hasExtension(extension Extension) bool {
+	if !reflect.TypeOf(extension).Comparable() {
+		return false
+	}
+	for _, e := range c.extensions {
+		if reflect.TypeOf(e) == reflect.TypeOf(extension) && e == extension {
+			return true
+		}
+	}
+	return false
+}
+
+// AddBlockParser makes the Converter try the given BlockParser when looking
+// for the start of a new block. Parsers are tried in order of increasing
+// priority value, so parsers with lower values take precedence. Parsers with
+// equal priority are tried in the order in which they were added.
+//
+// The built-in parsers have the following priorities: indented code blocks
+// 100, block quotes 200, fenced code blocks 250, HTML blocks 300, ATX headers
+// 400, setext headers 500, horizontal rules 600, list items 650 and paragraphs
+// 1000. The paragraph parser starts a paragraph on any line, so parsers with
+// a higher value are only reached for lines that continue an open paragraph.
+//
+// AddBlockParser must not be called once the Converter is in use.
+func (c *Converter) AddBlockParser(parser BlockParser, priority int) {
+	parsers := append(c.parseOptions.blockParsers, prioritizedBlockParser{parser, priority})
+	sort.SliceStable(parsers, func(i, j int) bool {
+		return parsers[i].priority < parsers[j].priority
+	})
+	c.parseOptions.blockParsers = parsers
+}
+
+// AddInlineParser makes the Converter try the given InlineParser whenever it
+// encounters the trigger character. Parsers for the same trigger are tried in
+// order of increasing priority value, and then in the order in which they were
+// added. All of them are tried before any built-in parsing of the character.
+//
+// AddInlineParser must not be called once the Converter is in use.
+func (c *Converter) AddInlineParser(trigger byte, parser InlineParser, priority int) {
+	if c.parseOptions.inlineParsers == nil {
+		c.parseOptions.inlineParsers = make(map[byte][]prioritizedInlineParser)
+	}
+	parsers := append(c.parseOptions.inlineParsers[trigger], prioritizedInlineParser{parser, priority})
+	sort.SliceStable(parsers, func(i, j int) bool {
+		return parsers[i].priority < parsers[j].priority
+	})
+	c.parseOptions.inlineParsers[trigger] = parsers
+}
+
+// AddDelimiterRule makes the Converter recognize a construct that is delimited
+// by runs of the rule's character; see DelimiterRule. Several rules can be
+// added for the same character, for different lengths of runs, like ~sub~ and
+// ~~strikethrough~~. If more than one rule applies to a run, the one that was
+// added first is used.
+//
+// The rule's character must not be one that has a built-in meaning in inline
+// content: a line break, space, tab, backtick, backslash, '<', '&', '[', ']',
+// '!', a quote, or '-' or '.', which smart punctuation turns into dashes and
+// ellipses (see WithSmartPunctuation). MinLength must be at least 1, MaxLength must be at least
+// MinLength, and Wrap must not be nil. AddDelimiterRule panics otherwise.
+//
+// AddDelimiterRule must not be called once the Converter is in use.
+func (c *Converter) AddDelimiterRule(rule DelimiterRule) {
+	assertf(strings.IndexByte(reservedDelimiterChars, rule.Char) < 0, "DelimiterRule cannot use reserved character %q", rule.Char)
+	assertf(rule.MinLength >= 1 && rule.MaxLength >= rule.MinLength, "DelimiterRule for %q has invalid lengths %d-%d", rule.Char, rule.MinLength, rule.MaxLength)
+	assertf(rule.Wrap != nil, "DelimiterRule for %q has no Wrap function", rule.Char)
+	if c.parseOptions.delimiterRules == nil {
+		c.parseOptions.delimiterRules = make(map[byte][]*DelimiterRule)
+	}
+	c.parseOptions.delimiterRules[rule.Char] = append(c.parseOptions.delimiterRules[rule.Char], &rule)
+}
+
+// reservedDelimiterChars are the characters that the inline parser gives a
+// built-in meaning, which a DelimiterRule would take away from them.
+const reservedDelimiterChars = "\n \t`\\<&[]!'\"-."
+
+// AddTransform makes the Converter run the given Transform on the parse tree
+// at the given phase. Transforms for the same phase are run in order of
+// increasing priority value, and then in the order in which they were added.
+//
+// AddTransform must not be called once the Converter is in use.
+func (c *Converter) AddTransform(phase TransformPhase, transform Transform, priority int) {
+	if c.parseOptions.transforms == nil {
+		c.parseOptions.transforms = make(map[TransformPhase][]prioritizedTransform)
+	}
+	transforms := append(c.parseOptions.transforms[phase], prioritizedTransform{transform, priority})
+	sort.SliceStable(transforms, func(i, j int) bool {
+		return transforms[i].priority < transforms[j].priority
+	})
+	c.parseOptions.transforms[phase] = transforms
+}
+
+// Renderer returns the HTMLRenderer that the Converter uses, so that render
+// functions can be registered on it. It must not be modified once the
+// Converter is in use.
+func (c *Converter) Renderer() *HTMLRenderer {
+	return c.renderer
+}
+
+type prioritizedBlockParser struct {
+	parser   BlockParser
+	priority int
+}
+
+type prioritizedInlineParser struct {
+	parser   InlineParser
+	priority int
+}
+
+type prioritizedTransform struct {
+	transform Transform
+	priority  int
+}
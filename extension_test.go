@@ -0,0 +1,63 @@
+package commonmark
+
+import (
+	"testing"
+)
+
+type asideExtension struct{}
+
+func (asideExtension) Extend(c *Converter) {
+	// Between indented code blocks and block quotes.
+	c.AddBlockParser(asideParser{}, 150)
+	c.Renderer().SetBlockFunc(&aside{}, renderAside)
+}
+
+type countingExtension struct {
+	count *int
+}
+
+func (e countingExtension) Extend(c *Converter) {
+	*e.count++
+}
+
+type bundleExtension []Extension
+
+func (b bundleExtension) Extend(c *Converter) {
+	WithExtensions(b...)(c)
+}
+
+func TestExtensions(t *testing.T) {
+	runHTMLTests(t, New(WithExtensions(asideExtension{})), []htmlTest{
+		{"| a\n", "<aside>\n<p>a</p>\n</aside>\n"},
+		{"    | a\n", "<pre><code>| a\n</code></pre>\n"},
+	})
+
+	var count int
+	counting := countingExtension{&count}
+	New(WithExtensions(counting, bundleExtension{asideExtension{}, counting}), WithExtensions(counting))
+	if count != 1 {
+		t.Errorf("expected extension to be enabled once, but it was enabled %d times", count)
+	}
+}
+
+func TestParserPriorities(t *testing.T) {
+	c := New()
+	c.AddBlockParser(asideParser{}, 1000)
+	c.AddBlockParser(commentParser{}, 150)
+	var priorities []int
+	for _, p := range c.parseOptions.blockParsers {
+		priorities = append(priorities, p.priority)
+	}
+	expected := []int{100, 150, 200, 250, 300, 400, 500, 600, 650, 1000, 1000}
+	if len(priorities) != len(expected) {
+		t.Fatalf("expected priorities %v, got %v", expected, priorities)
+	}
+	for i := range expected {
+		if priorities[i] != expected[i] {
+			t.Fatalf("expected priorities %v, got %v", expected, priorities)
+		}
+	}
+	if _, ok := c.parseOptions.blockParsers[len(expected)-1].parser.(asideParser); !ok {
+		t.Errorf("expected parser with equal priority to be added last")
+	}
+}
@@ -0,0 +1,274 @@
+package commonmark
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strconv"
+)
+
+// Footnotes is an Extension that adds support for footnotes, in the syntax
+// used by GitHub and PHP Markdown Extra. A footnote reference looks like [^1],
+// and the footnote is defined elsewhere in the document by a block starting
+// with [^1]: and continued by lines indented by four spaces.
+//
+// Labels are matched like the labels of link reference definitions. Footnotes
+// are numbered in the order in which they are first referenced, and rendered
+// in a section at the end of the document, with links back to the references.
+// Definitions that are never referenced are dropped, and references without a
+// definition are literal text. Like on GitHub, [^label] followed by (, [ or :
+// is not a reference, so that it can still be parsed as a link, and neither
+// is [^label] inside the text of a link, because links may not contain other
+// links.
+var Footnotes Extension = footnotesExtension{}
+
+type footnotesExtension struct{}
+
+func (footnotesExtension) Extend(c *Converter) {
+	c.AddBlockParser(footnoteDefinitionParser{}, 350)
+	c.AddInlineParser('[', footnoteReferenceParser{}, 0)
+	c.AddTransform(AfterInlineParsing, resolveFootnotes, 0)
+	c.Renderer().SetBlockFunc(&FootnoteDefinition{}, renderFootnoteDefinition)
+	c.Renderer().SetBlockFunc(&FootnoteList{}, renderFootnoteList)
+	c.Renderer().SetInlineFunc(&FootnoteReference{}, renderFootnoteReference)
+}
+
+// FootnoteDefinition is the definition of a footnote, which can contain any
+// blocks.
+type FootnoteDefinition struct {
+	BaseBlock
+	// Label is the label of the definition, as written.
+	Label []byte
+	// Number is the number of the footnote, counting from 1 in the order in
+	// which the footnotes are first referenced.
+	Number int
+	// References is the number of references to the footnote.
+	References int
+}
+
+func (d *FootnoteDefinition) CanContain(Block) bool {
+	return true
+}
+
+// FootnoteList holds the footnotes that are referenced in the document, in
+// order of their numbers. It is appended to the end of the document.
+type FootnoteList struct {
+	BaseBlock
+}
+
+// FootnoteReference is a reference to a footnote.
+type FootnoteReference struct {
+	// Label is the label of the reference, as written.
+	Label []byte
+	// Definition is the definition that the reference refers to, or nil if
+	// there is none, in which case the reference is rendered as literal
+	// text.
+	Definition *FootnoteDefinition
+	// Index counts the references to the same footnote, starting at 1.
+	Index int
+}
+
+// A footnote label is like a link label, but may not contain whitespace.
+const footnoteLabel = `\[\^([^\]\s]+)\]`
+
+var (
+	footnoteDefinitionRe = regexp.MustCompile(`^ {0,3}` + footnoteLabel + `: *`)
+	footnoteReferenceRe  = regexp.MustCompile(`^` + footnoteLabel)
+)
+
+type footnoteDefinitionParser struct{}
+
+// Start starts a footnote definition. Like on GitHub, it can interrupt a
+// paragraph, so that definitions need not be separated by blank lines.
+func (footnoteDefinitionParser) Start(s BlockParserState, line []byte) ([]byte, bool) {
+	m := footnoteDefinitionRe.FindSubmatchIndex(line)
+	if m == nil {
+		return nil, false
+	}
+	s.AddBlock(&FootnoteDefinition{Label: line[m[2]:m[3]]}, footnoteDefinitionParser{})
+	if line[m[1]] == '\n' {
+		return nil, true
+	}
+	return line[m[1]:], true
+}
+
+// Continue continues the definition on blank lines, and on lines indented by
+// four or more spaces.
+func (footnoteDefinitionParser) Continue(b Block, line []byte) ([]byte, bool) {
+	if isBlank(line) {
+		if len(line) > 4 {
+			return line[4:], true
+		}
+		return line[len(line)-1:], true
+	}
+	if indentation(line) < 4 {
+		return nil, false
+	}
+	return line[4:], true
+}
+
+func (footnoteDefinitionParser) Close(b Block) {}
+
+type footnoteReferenceParser struct{}
+
+func (footnoteReferenceParser) Parse(s InlineParserState) (Inline, int) {
+	if s.InLinkText() {
+		return nil, 0
+	}
+	data := s.Data()[s.Pos():]
+	m := footnoteReferenceRe.FindSubmatch(data)
+	if m == nil {
+		return nil, 0
+	}
+	if len(data) > len(m[0]) && bytes.IndexByte([]byte("([:"), data[len(m[0])]) >= 0 {
+		return nil, 0
+	}
+	return &FootnoteReference{Label: m[1]}, len(m[0])
+}
+
+// resolveFootnotes is a Transform that links references to definitions,
+// numbers the footnotes, and moves the definitions of referenced footnotes to
+// a FootnoteList at the end of the document.
+func resolveFootnotes(doc *Document) error {
+	// "If there are multiple matching reference link definitions, the one
+	// that comes first in the document is used."
+	definitions := make(map[string]*FootnoteDefinition)
+	walkBlocks(doc, func(b Block) {
+		if def, ok := b.(*FootnoteDefinition); ok {
+			label := normalizeLabel(def.Label)
+			if definitions[label] == nil {
+				definitions[label] = def
+			}
+		}
+	})
+
+	var numbered []*FootnoteDefinition
+	walkBlocks(doc, func(b Block) {
+		c, ok := b.(InlineContainer)
+		if !ok || c.ParsedInlineContent() == nil {
+			return
+		}
+		walkInlines(c.ParsedInlineContent(), func(i Inline) {
+			ref, ok := i.(*FootnoteReference)
+			if !ok {
+				return
+			}
+			def := definitions[normalizeLabel(ref.Label)]
+			if def == nil {
+				return
+			}
+			if def.Number == 0 {
+				numbered = append(numbered, def)
+				def.Number = len(numbered)
+			}
+			def.References++
+			ref.Definition = def
+			ref.Index = def.References
+		})
+	})
+
+	removeFootnoteDefinitions(doc)
+	if len(numbered) == 0 {
+		return nil
+	}
+	list := &FootnoteList{}
+	for _, def := range numbered {
+		list.AppendChild(def)
+	}
+	doc.AppendChild(list)
+	return nil
+}
+
+// removeFootnoteDefinitions removes all FootnoteDefinitions from the tree,
+// wherever they are.
+func removeFootnoteDefinitions(b Block) {
+	var children []Block
+	for _, child := range b.Children() {
+		if _, ok := child.(*FootnoteDefinition); !ok {
+			children = append(children, child)
+		}
+	}
+	if len(children) < len(b.Children()) {
+		b.SetChildren(children)
+	}
+	for _, child := range b.Children() {
+		removeFootnoteDefinitions(child)
+	}
+}
+
+// footnoteID returns the value of the id attribute of the footnote, or of its
+// reference with the given index if it is positive.
+func footnoteID(def *FootnoteDefinition, index int) []byte {
+	if index <= 0 {
+		return []byte("fn-" + string(def.Label))
+	}
+	id := "fnref-" + string(def.Label)
+	if index > 1 {
+		id += "-" + strconv.Itoa(index)
+	}
+	return []byte(id)
+}
+
+func renderFootnoteReference(r *HTMLRenderer, i Inline, out io.Writer) {
+	ref := i.(*FootnoteReference)
+	if ref.Definition == nil {
+		WriteEscaped([]byte("[^"+string(ref.Label)+"]"), out)
+		return
+	}
+	io.WriteString(out, "<sup")
+	r.WriteAttr("class", []byte("footnote-ref"), out)
+	io.WriteString(out, "><a")
+	r.WriteAttr("href", append([]byte("#"), footnoteID(ref.Definition, 0)...), out)
+	r.WriteAttr("id", footnoteID(ref.Definition, ref.Index), out)
+	io.WriteString(out, ">"+strconv.Itoa(ref.Definition.Number)+"</a></sup>")
+}
+
+func renderFootnoteList(r *HTMLRenderer, b Block, out io.Writer) {
+	io.WriteString(out, "<section")
+	r.WriteAttr("class", []byte("footnotes"), out)
+	io.WriteString(out, ">\n<ol>\n")
+	r.RenderChildren(b, out)
+	io.WriteString(out, "</ol>\n</section>\n")
+}
+
+// renderFootnoteDefinition renders the footnote as a list item. The links
+// back to the references are added to its last paragraph, or to a paragraph
+// of their own if it does not end in one.
+func renderFootnoteDefinition(r *HTMLRenderer, b Block, out io.Writer) {
+	def := b.(*FootnoteDefinition)
+	io.WriteString(out, "<li")
+	r.WriteAttr("id", footnoteID(def, 0), out)
+	io.WriteString(out, ">\n")
+	children := def.Children()
+	last, endsInParagraph := Block(nil), false
+	if len(children) > 0 {
+		last = children[len(children)-1]
+		_, endsInParagraph = last.(*Paragraph)
+	}
+	for _, child := range children {
+		if child != last || !endsInParagraph {
+			r.RenderBlock(child, out)
+		}
+	}
+	io.WriteString(out, "<p>")
+	if endsInParagraph {
+		r.RenderInline(last.(*Paragraph).InlineContent, out)
+		io.WriteString(out, " ")
+	}
+	for index := 1; index <= def.References; index++ {
+		if index > 1 {
+			io.WriteString(out, " ")
+		}
+		io.WriteString(out, "<a")
+		r.WriteAttr("href", append([]byte("#"), footnoteID(def, index)...), out)
+		r.WriteAttr("class", []byte("footnote-backref"), out)
+		io.WriteString(out, ">↩")
+		if index > 1 {
+			io.WriteString(out, "<sup")
+			r.WriteAttr("class", []byte("footnote-ref"), out)
+			io.WriteString(out, ">"+strconv.Itoa(index)+"</sup>")
+		}
+		io.WriteString(out, "</a>")
+	}
+	io.WriteString(out, "</p>\n</li>\n")
+}
@@ -0,0 +1,55 @@
+package commonmark
+
+import (
+	"testing"
+)
+
+func TestFootnotes(t *testing.T) {
+	runHTMLTests(t, New(WithExtensions(Footnotes)), []htmlTest{
+		{"Text[^1] and more[^Note].\n\n[^note]: The note.\n[^1]: The first.\n",
+			"<p>Text<sup class=\"footnote-ref\"><a href=\"#fn-1\" id=\"fnref-1\">1</a></sup> and more<sup class=\"footnote-ref\"><a href=\"#fn-note\" id=\"fnref-note\">2</a></sup>.</p>\n" +
+				"<section class=\"footnotes\">\n<ol>\n" +
+				"<li id=\"fn-1\">\n<p>The first. <a href=\"#fnref-1\" class=\"footnote-backref\">↩</a></p>\n</li>\n" +
+				"<li id=\"fn-note\">\n<p>The note. <a href=\"#fnref-note\" class=\"footnote-backref\">↩</a></p>\n</li>\n" +
+				"</ol>\n</section>\n"},
+		{"a[^x] b[^x]\n\n[^x]: One.\n\n    Two.\n\n        code\n",
+			"<p>a<sup class=\"footnote-ref\"><a href=\"#fn-x\" id=\"fnref-x\">1</a></sup> b<sup class=\"footnote-ref\"><a href=\"#fn-x\" id=\"fnref-x-2\">1</a></sup></p>\n" +
+				"<section class=\"footnotes\">\n<ol>\n" +
+				"<li id=\"fn-x\">\n<p>One.</p>\n<p>Two.</p>\n<pre><code>code\n</code></pre>\n<p><a href=\"#fnref-x\" class=\"footnote-backref\">↩</a> <a href=\"#fnref-x-2\" class=\"footnote-backref\">↩<sup class=\"footnote-ref\">2</sup></a></p>\n</li>\n" +
+				"</ol>\n</section>\n"},
+		{"No [^such] note.\n\n[^unused]: Dropped.\n",
+			"<p>No [^such] note.</p>\n"},
+		{"> [^a]\n\n[^a]: A [^a] in itself.\nlazy\n",
+			"<blockquote>\n<p><sup class=\"footnote-ref\"><a href=\"#fn-a\" id=\"fnref-a\">1</a></sup></p>\n</blockquote>\n" +
+				"<section class=\"footnotes\">\n<ol>\n" +
+				"<li id=\"fn-a\">\n<p>A <sup class=\"footnote-ref\"><a href=\"#fn-a\" id=\"fnref-a-2\">1</a></sup> in itself.\nlazy <a href=\"#fnref-a\" class=\"footnote-backref\">↩</a> <a href=\"#fnref-a-2\" class=\"footnote-backref\">↩<sup class=\"footnote-ref\">2</sup></a></p>\n</li>\n" +
+				"</ol>\n</section>\n"},
+		{"para\n[^1]: unused\n",
+			"<p>para</p>\n"},
+		{"[^such](/url) [^1][x] [^1]: y\n\n[^1]: Note.\n",
+			"<p><a href=\"/url\">^such</a> [^1][x] [^1]: y</p>\n"},
+		{"[foo [^1]](/u)\n\n[^1]: Note.\n",
+			"<p><a href=\"/u\">foo [^1]</a></p>\n"},
+	})
+	runHTMLTests(t, New(WithExtensions(Footnotes, Superscript)), []htmlTest{
+		{"[^a^](/u)\n", "<p><a href=\"/u\"><sup>a</sup></a></p>\n"},
+	})
+	converter := New(
+		WithExtensions(Footnotes),
+		WithBlockParsers(boxParser{}, captionParser{}),
+		WithBlockRenderFunc(&box{}, renderBox),
+		WithBlockRenderFunc(&caption{}, renderCaption),
+	)
+	runHTMLTests(t, converter, []htmlTest{
+		{"; a[^1]\n; \n; [^1]: Note.\n",
+			"<div>\n<p>a<sup class=\"footnote-ref\"><a href=\"#fn-1\" id=\"fnref-1\">1</a></sup></p>\n</div>\n" +
+				"<section class=\"footnotes\">\n<ol>\n" +
+				"<li id=\"fn-1\">\n<p>Note. <a href=\"#fnref-1\" class=\"footnote-backref\">↩</a></p>\n</li>\n" +
+				"</ol>\n</section>\n"},
+		{"^ a[^1]\n\n[^1]: Note.\n",
+			"<figcaption>a<sup class=\"footnote-ref\"><a href=\"#fn-1\" id=\"fnref-1\">1</a></sup></figcaption>\n" +
+				"<section class=\"footnotes\">\n<ol>\n" +
+				"<li id=\"fn-1\">\n<p>Note. <a href=\"#fnref-1\" class=\"footnote-backref\">↩</a></p>\n</li>\n" +
+				"</ol>\n</section>\n"},
+	})
+}
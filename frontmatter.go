@@ -0,0 +1,93 @@
+package commonmark
+
+import (
+	"bytes"
+)
+
+// FrontMatterFormat is the format of the front matter of a document, as
+// indicated by its delimiter lines.
+type FrontMatterFormat int
+
+const (
+	// YAMLFrontMatter is delimited by lines of three dashes (---). Like in
+	// YAML itself, the closing line may also be three dots (...).
+	YAMLFrontMatter FrontMatterFormat = iota + 1
+	// TOMLFrontMatter is delimited by lines of three plus signs (+++).
+	TOMLFrontMatter
+)
+
+// FrontMatter is a block of metadata at the very start of a document, which
+// is not part of the Markdown content. See WithFrontMatter.
+type FrontMatter struct {
+	Format FrontMatterFormat
+	// Raw holds the lines between the delimiter lines, including their line
+	// endings.
+	Raw []byte
+	// Lines is the number of lines of the input that the front matter takes
+	// up, including the delimiter lines. It is informational only: the front
+	// matter is replaced by as many blank lines before the Markdown content
+	// is parsed, so line numbers in the content match those of the input.
+	Lines int
+	// Data holds the result of the FrontMatterDecoder, if any.
+	Data interface{}
+}
+
+// FrontMatterDecoder decodes the raw bytes of front matter of the given
+// format, typically using a YAML or TOML library.
+type FrontMatterDecoder func(format FrontMatterFormat, raw []byte) (interface{}, error)
+
+// WithFrontMatter makes the Converter recognize front matter at the very
+// start of the input: a line consisting of --- (for YAML) or +++ (for TOML),
+// followed by any lines, up to a line with the same delimiter. The front
+// matter is not parsed as Markdown, but stored in the FrontMatter field of the
+// Document. Without a closing delimiter line, there is no front matter.
+//
+// If decoder is not nil, it is called on the front matter, and its result is
+// stored in the Data field of the FrontMatter. If it returns an error, parsing
+// fails with that error.
+func WithFrontMatter(decoder FrontMatterDecoder) Option {
+	return func(c *Converter) {
+		c.parseOptions.frontMatter = true
+		c.parseOptions.frontMatterDecoder = decoder
+	}
+}
+
+// extractFrontMatter splits the front matter, if any, from the start of the
+// data. It returns the front matter, or nil if there is none, and the data
+// with the front matter replaced by an equal number of blank lines.
+func extractFrontMatter(data []byte) (*FrontMatter, []byte) {
+	advance, line, _ := scanLines(data, true)
+	var format FrontMatterFormat
+	switch string(bytes.TrimRight(line, " \t")) {
+	case "---":
+		format = YAMLFrontMatter
+	case "+++":
+		format = TOMLFrontMatter
+	default:
+		return nil, data
+	}
+
+	start := advance
+	lines := 1
+	for pos := start; pos < len(data); pos += advance {
+		advance, line, _ = scanLines(data[pos:], true)
+		lines++
+		if isFrontMatterEnd(format, line) {
+			rest := append(bytes.Repeat([]byte("\n"), lines), data[pos+advance:]...)
+			return &FrontMatter{Format: format, Raw: data[start:pos], Lines: lines}, rest
+		}
+	}
+	return nil, data
+}
+
+// isFrontMatterEnd returns whether the line closes front matter of the given
+// format.
+func isFrontMatterEnd(format FrontMatterFormat, line []byte) bool {
+	switch string(bytes.TrimRight(line, " \t")) {
+	case "---", "...":
+		return format == YAMLFrontMatter
+	case "+++":
+		return format == TOMLFrontMatter
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package commonmark
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFrontMatter(t *testing.T) {
+	runHTMLTests(t, New(WithFrontMatter(nil)), []htmlTest{
+		{"---\ntitle: Hello\n---\n# Body\n", "<h1>Body</h1>\n"},
+		{"+++\ntitle = \"Hello\"\n+++\nText\n", "<p>Text</p>\n"},
+		{"---\na: 1\n...\nText\n", "<p>Text</p>\n"},
+		{"---\n---\nText\n", "<p>Text</p>\n"},
+		{"---\na: 1\n---\n    code\n", "<pre><code>code\n</code></pre>\n"},
+		{"---\ntitle: Hello\n", "<hr />\n<p>title: Hello</p>\n"},
+		{"+++\ntitle: Hello\n---\n", "<p>+++\ntitle: Hello</p>\n<hr />\n"},
+		{"Text\n---\na\n---\n", "<h2>Text</h2>\n<h2>a</h2>\n"},
+		{" ---\na\n---\n", "<hr />\n<h2>a</h2>\n"},
+	})
+	runHTMLTests(t, New(), []htmlTest{
+		{"---\ntitle: Hello\n---\n", "<hr />\n<h2>title: Hello</h2>\n"},
+	})
+}
+
+func TestFrontMatterDocument(t *testing.T) {
+	var decoded []byte
+	decoder := func(format FrontMatterFormat, raw []byte) (interface{}, error) {
+		if format != YAMLFrontMatter {
+			t.Errorf("expected format %v, got %v", YAMLFrontMatter, format)
+		}
+		decoded = raw
+		return "decoded", nil
+	}
+	doc, err := New(WithFrontMatter(decoder)).Parse([]byte("---\r\na: 1\r\nb: 2\r\n---\r\n\r\nText\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := &FrontMatter{Format: YAMLFrontMatter, Raw: []byte("a: 1\r\nb: 2\r\n"), Lines: 4, Data: "decoded"}
+	if !reflect.DeepEqual(doc.FrontMatter, expected) {
+		t.Errorf("expected front matter %+v, got %+v", expected, doc.FrontMatter)
+	}
+	if string(decoded) != "a: 1\r\nb: 2\r\n" {
+		t.Errorf("decoder got %q", decoded)
+	}
+
+	doc, err = New(WithFrontMatter(decoder)).Parse([]byte("Text\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.FrontMatter != nil {
+		t.Errorf("expected no front matter, got %+v", doc.FrontMatter)
+	}
+
+	decodeErr := errors.New("invalid YAML")
+	_, err = New(WithFrontMatter(func(FrontMatterFormat, []byte) (interface{}, error) {
+		return nil, decodeErr
+	})).Parse([]byte("---\n:\n---\n"))
+	if err != decodeErr {
+		t.Errorf("expected error %v, got %v", decodeErr, err)
+	}
+}
@@ -0,0 +1,98 @@
+package commonmark
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Slugger turns the plain text of a header into a slug, which is suitable as
+// the value of an id attribute and as the fragment of a URL.
+type Slugger func(text string) string
+
+// WithHeaderIDs makes the Converter give every header an id attribute, which
+// is derived from its plain text by the given Slugger; if it is nil,
+// GitHubSlug is used. If several headers get the same slug, the second one
+// gets the suffix -1, the third one -2, and so on, like on GitHub. Headers
+// with an empty slug get no ID, and headers that already have an ID, set by
+// a Transform that runs earlier, keep it.
+//
+// The ID is stored in the ID field of the Header.
+func WithHeaderIDs(slugger Slugger) Option {
+	if slugger == nil {
+		slugger = GitHubSlug
+	}
+	return func(c *Converter) {
+		c.AddTransform(AfterInlineParsing, func(doc *Document) error {
+			assignHeaderIDs(doc, slugger)
+			return nil
+		}, 0)
+	}
+}
+
+// assignHeaderIDs sets the ID of every header that doesn't have one yet to a
+// unique slug.
+func assignHeaderIDs(doc *Document, slugger Slugger) {
+	used := make(map[string]bool)
+	walkBlocks(doc, func(b Block) {
+		if h, ok := b.(*Header); ok && h.ID != "" {
+			used[h.ID] = true
+		}
+	})
+	counts := make(map[string]int)
+	walkBlocks(doc, func(b Block) {
+		h, ok := b.(*Header)
+		if !ok || h.ID != "" {
+			return
+		}
+		slug := slugger(string(PlainText(h.InlineContent)))
+		if slug == "" {
+			return
+		}
+		id := slug
+		for used[id] {
+			counts[slug]++
+			id = slug + "-" + strconv.Itoa(counts[slug])
+		}
+		used[id] = true
+		h.ID = id
+	})
+}
+
+// GitHubSlug is a Slugger that produces the same slugs as GitHub: the text is
+// lowercased, all characters other than letters, digits, marks, spaces,
+// hyphens and underscores are removed, and each space is replaced by a
+// hyphen. For example, "What's new in 2.0?" becomes "whats-new-in-20".
+func GitHubSlug(text string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ' ':
+			return '-'
+		case r == '-' || r == '_' || unicode.In(r, unicode.Letter, unicode.Digit, unicode.Mark, unicode.Pc):
+			return unicode.ToLower(r)
+		default:
+			return -1
+		}
+	}, strings.TrimSpace(text))
+}
+
+// UnicodeSlug is a Slugger that keeps as much of the text as possible: only
+// ASCII punctuation other than hyphens, underscores and periods is removed,
+// and runs of whitespace become a single hyphen. Letters are lowercased, but
+// all other characters, including symbols like emoji, are preserved. For
+// example, "Café ☕ & Crème" becomes "café-☕-crème".
+func UnicodeSlug(text string) string {
+	var words []string
+	for _, word := range strings.Fields(text) {
+		word = strings.Map(func(r rune) rune {
+			if r < unicode.MaxASCII && isASCIIPunct(byte(r)) && r != '-' && r != '_' && r != '.' {
+				return -1
+			}
+			return unicode.ToLower(r)
+		}, word)
+		if word != "" {
+			words = append(words, word)
+		}
+	}
+	return strings.Join(words, "-")
+}
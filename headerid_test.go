@@ -0,0 +1,64 @@
+package commonmark
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+// badge is a custom Inline with children, written as {text}.
+type badge struct {
+	Children []Inline
+}
+
+func (b *badge) InlineChildren() []Inline {
+	return b.Children
+}
+
+type badgeParser struct{}
+
+func (badgeParser) Parse(s InlineParserState) (Inline, int) {
+	data := s.Data()[s.Pos():]
+	end := bytes.IndexByte(data, '}')
+	if end < 0 {
+		return nil, 0
+	}
+	return &badge{[]Inline{&StringInline{data[1:end]}}}, end + 1
+}
+
+func TestHeaderIDs(t *testing.T) {
+	runHTMLTests(t, New(WithHeaderIDs(nil)), []htmlTest{
+		{"# Hello, World!\n\nText\n---\n", "<h1 id=\"hello-world\">Hello, World!</h1>\n<h2 id=\"text\">Text</h2>\n"},
+		{"# foo\n# foo\n# foo-1\n# foo\n", "<h1 id=\"foo\">foo</h1>\n<h1 id=\"foo-1\">foo</h1>\n<h1 id=\"foo-1-1\">foo-1</h1>\n<h1 id=\"foo-2\">foo</h1>\n"},
+		{"## What's `new` in *2.0*?\n", "<h2 id=\"whats-new-in-20\">What's <code>new</code> in *2.0*?</h2>\n"},
+		{"# Über  <b>bold</b> ☕\n", "<h1 id=\"über--bold-\">Über  <b>bold</b> ☕</h1>\n"},
+		{"> # a \"quote\"\n", "<blockquote>\n<h1 id=\"a-quote\">a &quot;quote&quot;</h1>\n</blockquote>\n"},
+	})
+	runHTMLTests(t, New(WithHeaderIDs(UnicodeSlug)), []htmlTest{
+		{"# Café ☕ & Crème\n# v1.0 (beta)\n# &\n# &\n", "<h1 id=\"café-☕-crème\">Café ☕ &amp; Crème</h1>\n<h1 id=\"v1.0-beta\">v1.0 (beta)</h1>\n<h1>&amp;</h1>\n<h1>&amp;</h1>\n"},
+	})
+	converter := New(
+		WithTransform(AfterInlineParsing, func(doc *Document) error {
+			doc.Children()[0].(*Header).ID = "intro"
+			return nil
+		}),
+		WithHeaderIDs(func(text string) string { return "intro" }),
+	)
+	runHTMLTests(t, converter, []htmlTest{
+		{"# a\n# b\n", "<h1 id=\"intro\">a</h1>\n<h1 id=\"intro-1\">b</h1>\n"},
+	})
+	converter = New(
+		WithInlineParser('{', badgeParser{}),
+		WithInlineRenderFunc(&badge{}, func(r *HTMLRenderer, i Inline, out io.Writer) {
+			io.WriteString(out, "<span>")
+			for _, child := range i.(*badge).Children {
+				r.RenderInline(child, out)
+			}
+			io.WriteString(out, "</span>")
+		}),
+		WithHeaderIDs(nil),
+	)
+	runHTMLTests(t, converter, []htmlTest{
+		{"# Hello {World}\n", "<h1 id=\"hello-world\">Hello <span>World</span></h1>\n"},
+	})
+}
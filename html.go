@@ -1,63 +1,427 @@
 package commonmark
 
 import (
+	"bytes"
 	"fmt"
 	"io"
 	"log"
+	"reflect"
+	"strconv"
+	"strings"
 )
 
-func blockToHTML(b Block, out io.Writer) {
+// HTMLFlavor selects the syntax used for the HTML that is generated from
+// CommonMark constructs. Raw HTML from the input is passed through as-is,
+// regardless of the flavor.
+type HTMLFlavor int
+
+const (
+	// XHTML writes void elements with a closing slash (<br />) and boolean
+	// attributes with their name as the value (disabled="disabled"). It is
+	// the default, and matches the output given in the CommonMark spec.
+	XHTML HTMLFlavor = iota
+	// HTML5 writes void elements without a closing slash (<br>) and boolean
+	// attributes without a value (disabled).
+	HTML5
+)
+
+// AttributeQuote is the quote character written around attribute values.
+type AttributeQuote byte
+
+const (
+	// DoubleQuotes writes attribute values in double quotes (a="b"). It is
+	// the default.
+	DoubleQuotes AttributeQuote = '"'
+	// SingleQuotes writes attribute values in single quotes (a='b').
+	SingleQuotes AttributeQuote = '\''
+)
+
+// BlockRenderFunc writes the HTML for a Block of a particular type. The
+// renderer is passed in so that the function can render children and inline
+// content, and respect the renderer's options.
+type BlockRenderFunc func(r *HTMLRenderer, b Block, out io.Writer)
+
+// InlineRenderFunc writes the HTML for an Inline of a particular type.
+type InlineRenderFunc func(r *HTMLRenderer, i Inline, out io.Writer)
+
+// HTMLRenderer converts a parse tree to HTML. It holds a render function for
+// each type of Block and Inline; these can be replaced to customize the output
+// for a built-in type, or added to support custom types.
+type HTMLRenderer struct {
+	blockFuncs  map[reflect.Type]BlockRenderFunc
+	inlineFuncs map[reflect.Type]InlineRenderFunc
+
+	// policy, if not nil, filters all raw HTML in the output.
+	policy *HTMLPolicy
+	// tagFilter escapes the tags that GFM disallows in raw HTML.
+	tagFilter bool
+	// flavor determines the syntax of void elements and attributes.
+	flavor HTMLFlavor
+	// quote is written around attribute values.
+	quote AttributeQuote
+	// hardWraps makes soft line breaks render as hard line breaks.
+	hardWraps bool
+	// softBreak is written for soft line breaks, unless hardWraps is set.
+	softBreak string
+}
+
+// NewHTMLRenderer returns a renderer with render functions for all built-in
+// Block and Inline types, which produce the HTML given in the CommonMark
+// spec.
+func NewHTMLRenderer() *HTMLRenderer {
+	r := &HTMLRenderer{
+		blockFuncs:  make(map[reflect.Type]BlockRenderFunc),
+		inlineFuncs: make(map[reflect.Type]InlineRenderFunc),
+		quote:       DoubleQuotes,
+		softBreak:   "\n",
+	}
+	r.SetBlockFunc(&Document{}, renderChildren)
+	r.SetBlockFunc(&HorizontalRule{}, renderHorizontalRule)
+	r.SetBlockFunc(&Header{}, renderHeader)
+	r.SetBlockFunc(&IndentedCodeBlock{}, renderIndentedCodeBlock)
+	r.SetBlockFunc(&FencedCodeBlock{}, renderFencedCodeBlock)
+	r.SetBlockFunc(&Paragraph{}, renderParagraph)
+	r.SetBlockFunc(&BlockQuote{}, renderBlockQuote)
+	r.SetBlockFunc(&HTMLBlock{}, renderHTMLBlock)
+	r.SetBlockFunc(&List{}, renderList)
+	r.SetBlockFunc(&ListItem{}, renderListItem)
+	r.SetInlineFunc(&StringInline{}, renderStringInline)
+	r.SetInlineFunc(&MultipleInline{}, renderMultipleInline)
+	r.SetInlineFunc(&SoftLineBreak{}, renderSoftLineBreak)
+	r.SetInlineFunc(&HardLineBreak{}, renderHardLineBreak)
+	r.SetInlineFunc(&CodeSpan{}, renderCodeSpan)
+	r.SetInlineFunc(&RawHTML{}, renderRawHTML)
+	r.SetInlineFunc(&Link{}, renderLink)
+	r.SetInlineFunc(&Image{}, renderImage)
+	return r
+}
+
+// SetBlockFunc sets the function used to render blocks of the same type as
+// the given one, replacing any existing function for that type. Typically, b
+// is a pointer to the zero value, e.g. &commonmark.Header{}.
+func (r *HTMLRenderer) SetBlockFunc(b Block, f BlockRenderFunc) {
+	r.blockFuncs[reflect.TypeOf(b)] = f
+}
+
+// SetInlineFunc sets the function used to render inlines of the same type as
+// the given one, replacing any existing function for that type.
+func (r *HTMLRenderer) SetInlineFunc(i Inline, f InlineRenderFunc) {
+	r.inlineFuncs[reflect.TypeOf(i)] = f
+}
+
+// SetHTMLPolicy sets the policy that filters all raw HTML in the output, or
+// disables filtering if policy is nil. See WithHTMLPolicy.
+func (r *HTMLRenderer) SetHTMLPolicy(policy *HTMLPolicy) {
+	r.policy = policy
+}
+
+// SetTagFilter enables or disables the GFM tag filter. See WithTagFilter.
+func (r *HTMLRenderer) SetTagFilter(enabled bool) {
+	r.tagFilter = enabled
+}
+
+// SetHTMLFlavor sets the syntax of the HTML output. See WithHTMLFlavor.
+func (r *HTMLRenderer) SetHTMLFlavor(flavor HTMLFlavor) {
+	r.flavor = flavor
+}
+
+// SetAttributeQuote sets the quote character written around attribute
+// values. See WithAttributeQuote.
+func (r *HTMLRenderer) SetAttributeQuote(quote AttributeQuote) {
+	r.quote = quote
+}
+
+// SetHardWraps sets whether soft line breaks are rendered as hard line
+// breaks. See WithHardWraps.
+func (r *HTMLRenderer) SetHardWraps(enabled bool) {
+	r.hardWraps = enabled
+}
+
+// SetSoftBreak sets the string written for soft line breaks when hard wraps
+// are disabled. See WithSoftBreak.
+func (r *HTMLRenderer) SetSoftBreak(softBreak string) {
+	r.softBreak = softBreak
+}
+
+// RenderBlock writes the HTML for the given block, including its children.
+func (r *HTMLRenderer) RenderBlock(b Block, out io.Writer) {
 	// Why not simply a method on Block? Extensibility: we want to support
 	// other (pluggable) output types than HTML, and also custom Block types.
-	switch t := b.(type) {
-	case *document:
-		for _, child := range t.Children() {
-			blockToHTML(child, out)
+	f, ok := r.blockFuncs[reflect.TypeOf(b)]
+	if !ok {
+		log.Panicf("no HTML converter registered for Block type %T", b)
+	}
+	f(r, b, out)
+}
+
+// RenderChildren writes the HTML for all children of the given block.
+func (r *HTMLRenderer) RenderChildren(b Block, out io.Writer) {
+	for _, child := range b.Children() {
+		r.RenderBlock(child, out)
+	}
+}
+
+// RenderInline writes the HTML for the given inline.
+func (r *HTMLRenderer) RenderInline(i Inline, out io.Writer) {
+	f, ok := r.inlineFuncs[reflect.TypeOf(i)]
+	if !ok {
+		log.Panicf("no HTML converter registered for Inline type %T", i)
+	}
+	f(r, i, out)
+}
+
+func renderChildren(r *HTMLRenderer, b Block, out io.Writer) {
+	r.RenderChildren(b, out)
+}
+
+func renderHorizontalRule(r *HTMLRenderer, b Block, out io.Writer) {
+	r.WriteVoidTag("hr", out)
+	io.WriteString(out, "\n")
+}
+
+func renderHeader(r *HTMLRenderer, b Block, out io.Writer) {
+	h := b.(*Header)
+	tag := "h" + strconv.Itoa(h.Level)
+	io.WriteString(out, "<"+tag)
+	var written []string
+	if h.ID != "" {
+		r.WriteAttr("id", []byte(h.ID), out)
+		written = append(written, "id")
+	}
+	r.WriteAttributes(tag, h.Attributes, written, out)
+	io.WriteString(out, ">")
+	r.RenderInline(h.InlineContent, out)
+	io.WriteString(out, "</"+tag+">\n")
+}
+
+func renderIndentedCodeBlock(r *HTMLRenderer, b Block, out io.Writer) {
+	io.WriteString(out, "<pre><code>")
+	WriteEscaped(b.(*IndentedCodeBlock).Content, out)
+	io.WriteString(out, "</code></pre>\n")
+}
+
+func renderFencedCodeBlock(r *HTMLRenderer, b Block, out io.Writer) {
+	code := b.(*FencedCodeBlock)
+	io.WriteString(out, "<pre")
+	r.WriteAttributes("pre", code.Attributes, nil, out)
+	io.WriteString(out, "><code")
+	if language := code.Language(); language != nil {
+		r.WriteAttr("class", append([]byte("language-"), language...), out)
+	}
+	io.WriteString(out, ">")
+	WriteEscaped(code.Content, out)
+	io.WriteString(out, "</code></pre>\n")
+}
+
+func renderParagraph(r *HTMLRenderer, b Block, out io.Writer) {
+	par := b.(*Paragraph)
+	io.WriteString(out, "<p")
+	r.WriteAttributes("p", par.Attributes, nil, out)
+	io.WriteString(out, ">")
+	r.RenderInline(par.InlineContent, out)
+	io.WriteString(out, "</p>\n")
+}
+
+func renderBlockQuote(r *HTMLRenderer, b Block, out io.Writer) {
+	io.WriteString(out, "<blockquote>\n")
+	r.RenderChildren(b, out)
+	io.WriteString(out, "</blockquote>\n")
+}
+
+func renderList(r *HTMLRenderer, b Block, out io.Writer) {
+	list := b.(*List)
+	tag := "ul"
+	if list.Ordered {
+		tag = "ol"
+	}
+	io.WriteString(out, "<"+tag)
+	if list.Ordered && list.Start != 1 {
+		r.WriteAttr("start", []byte(strconv.Itoa(list.Start)), out)
+	}
+	io.WriteString(out, ">\n")
+	r.RenderChildren(b, out)
+	io.WriteString(out, "</"+tag+">\n")
+}
+
+// renderListItem renders the children of the item between <li> tags, without
+// a newline before the closing tag. Paragraphs in tight lists are rendered
+// without <p> tags.
+func renderListItem(r *HTMLRenderer, b Block, out io.Writer) {
+	item := b.(*ListItem)
+	io.WriteString(out, "<li>")
+	r.renderItemChildren(item, item.Tight, out)
+	io.WriteString(out, "</li>\n")
+}
+
+// renderItemChildren renders the children of an item of a list-like block,
+// without a trailing newline. If the item is tight, paragraphs are rendered
+// without <p> tags.
+func (r *HTMLRenderer) renderItemChildren(item Block, tight bool, out io.Writer) {
+	var buffer bytes.Buffer
+	for _, child := range item.Children() {
+		if par, isParagraph := child.(*Paragraph); isParagraph && tight {
+			r.RenderInline(par.InlineContent, &buffer)
+			buffer.WriteByte('\n')
+		} else {
+			r.RenderBlock(child, &buffer)
 		}
-	case *horizontalRule:
-		io.WriteString(out, "<hr />\n")
-	case *atxHeader:
-		fmt.Fprintf(out, "<h%d>", t.level)
-		inlineToHTML(t.inlineContent, out)
-		fmt.Fprintf(out, "</h%d>\n", t.level)
-	case *indentedCodeBlock:
-		io.WriteString(out, "<pre><code>")
-		writeEscaped(t.content, out)
-		io.WriteString(out, "</code></pre>\n")
-	case *paragraph:
-		io.WriteString(out, "<p>")
-		inlineToHTML(t.inlineContent, out)
-		io.WriteString(out, "</p>\n")
-	case *blockQuote:
-		io.WriteString(out, "<blockquote>\n")
-		for _, child := range t.Children() {
-			blockToHTML(child, out)
+	}
+	out.Write(bytes.TrimSuffix(buffer.Bytes(), []byte("\n")))
+}
+
+func renderHTMLBlock(r *HTMLRenderer, b Block, out io.Writer) {
+	r.WriteRawHTML(b.(*HTMLBlock).Content, out)
+}
+
+func renderStringInline(r *HTMLRenderer, i Inline, out io.Writer) {
+	WriteEscaped(i.(*StringInline).Content, out)
+}
+
+func renderMultipleInline(r *HTMLRenderer, i Inline, out io.Writer) {
+	for _, child := range i.(*MultipleInline).Children {
+		r.RenderInline(child, out)
+	}
+}
+
+func renderSoftLineBreak(r *HTMLRenderer, i Inline, out io.Writer) {
+	if r.hardWraps {
+		renderHardLineBreak(r, i, out)
+	} else {
+		io.WriteString(out, r.softBreak)
+	}
+}
+
+func renderHardLineBreak(r *HTMLRenderer, i Inline, out io.Writer) {
+	r.WriteVoidTag("br", out)
+	io.WriteString(out, "\n")
+}
+
+func renderCodeSpan(r *HTMLRenderer, i Inline, out io.Writer) {
+	io.WriteString(out, "<code>")
+	WriteEscaped(i.(*CodeSpan).Content, out)
+	io.WriteString(out, "</code>")
+}
+
+func renderRawHTML(r *HTMLRenderer, i Inline, out io.Writer) {
+	r.WriteRawHTML(i.(*RawHTML).Content, out)
+}
+
+func renderLink(r *HTMLRenderer, i Inline, out io.Writer) {
+	link := i.(*Link)
+	io.WriteString(out, "<a")
+	r.WriteAttr("href", EscapeURL(link.Destination), out)
+	written := []string{"href"}
+	if len(link.Title) > 0 {
+		r.WriteAttr("title", link.Title, out)
+		written = append(written, "title")
+	}
+	r.WriteAttributes("a", link.Attributes, written, out)
+	io.WriteString(out, ">")
+	for _, child := range link.Children {
+		r.RenderInline(child, out)
+	}
+	io.WriteString(out, "</a>")
+}
+
+func renderImage(r *HTMLRenderer, i Inline, out io.Writer) {
+	image := i.(*Image)
+	io.WriteString(out, "<img")
+	r.WriteAttr("src", EscapeURL(image.Destination), out)
+	r.WriteAttr("alt", PlainText(&MultipleInline{image.Children}), out)
+	written := []string{"src", "alt"}
+	if len(image.Title) > 0 {
+		r.WriteAttr("title", image.Title, out)
+		written = append(written, "title")
+	}
+	r.WriteAttributes("img", image.Attributes, written, out)
+	r.EndVoidTag(out)
+}
+
+// EscapeURL percent-encodes the bytes in a URL that are not allowed in URLs,
+// like spaces and non-ASCII characters. Existing percent-encoded bytes are
+// left alone. The result must still be HTML-escaped, e.g. by WriteAttr.
+func EscapeURL(url []byte) []byte {
+	var escaped []byte
+	for i, c := range url {
+		if isURLSafe(c) || c == '%' && i+2 < len(url) && isHexDigit(url[i+1]) && isHexDigit(url[i+2]) {
+			escaped = append(escaped, c)
+		} else {
+			escaped = append(escaped, fmt.Sprintf("%%%02X", c)...)
 		}
-		io.WriteString(out, "</blockquote>\n")
+	}
+	return escaped
+}
+
+func isURLSafe(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+		strings.IndexByte("-_.!~*'();/?:@&=+$,#", c) >= 0
+}
+
+func isHexDigit(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F'
+}
+
+// WriteVoidTag writes a void element without attributes, such as <hr />, in
+// the renderer's HTML flavor.
+func (r *HTMLRenderer) WriteVoidTag(name string, out io.Writer) {
+	io.WriteString(out, "<"+name)
+	r.EndVoidTag(out)
+}
+
+// EndVoidTag writes the end of the start tag of a void element, which has no
+// end tag, in the renderer's HTML flavor. The '<', tag name and attributes
+// must have been written already.
+func (r *HTMLRenderer) EndVoidTag(out io.Writer) {
+	switch r.flavor {
+	case HTML5:
+		io.WriteString(out, ">")
 	default:
-		log.Panicf("no HTML converter registered for Block type %T", b)
+		io.WriteString(out, " />")
 	}
 }
 
-func inlineToHTML(i Inline, out io.Writer) {
-	switch t := i.(type) {
-	case *stringInline:
-		writeEscaped(t.content, out)
-	case *multipleInline:
-		for _, child := range t.children {
-			inlineToHTML(child, out)
+// WriteAttr writes an attribute, including a leading space, with the value
+// escaped and in the renderer's quote characters.
+func (r *HTMLRenderer) WriteAttr(name string, value []byte, out io.Writer) {
+	quote := string(r.quote)
+	io.WriteString(out, " "+name+"="+quote)
+	if r.quote == SingleQuotes {
+		// WriteEscaped leaves single quotes alone, so they are escaped
+		// separately.
+		for i, part := range bytes.Split(value, []byte("'")) {
+			if i > 0 {
+				io.WriteString(out, "&#39;")
+			}
+			WriteEscaped(part, out)
 		}
-	case *softLineBreak:
-		io.WriteString(out, "\n")
-	case *hardLineBreak:
-		io.WriteString(out, "<br />\n")
-	case *codeSpan:
-		io.WriteString(out, "<code>")
-		writeEscaped(t.content, out)
-		io.WriteString(out, "</code>")
+	} else {
+		WriteEscaped(value, out)
+	}
+	io.WriteString(out, quote)
+}
+
+// WriteBoolAttr writes a boolean attribute, including a leading space, in
+// the renderer's HTML flavor.
+func (r *HTMLRenderer) WriteBoolAttr(name string, out io.Writer) {
+	switch r.flavor {
+	case HTML5:
+		io.WriteString(out, " "+name)
 	default:
-		log.Panicf("no HTML converter registered for Inline type %T", i)
+		r.WriteAttr(name, []byte(name), out)
+	}
+}
+
+// WriteRawHTML writes raw HTML from the input, after passing it through the
+// renderer's tag filter and HTMLPolicy, if enabled.
+func (r *HTMLRenderer) WriteRawHTML(data []byte, out io.Writer) {
+	if r.tagFilter {
+		data = filterDisallowedTags(data)
+	}
+	if r.policy == nil {
+		out.Write(data)
+		return
 	}
+	r.policy.writeFiltered(data, out)
 }
 
 var escapeMap = map[byte]string{
@@ -67,7 +431,10 @@ var escapeMap = map[byte]string{
 	'>': "&gt;",
 }
 
-func writeEscaped(data []byte, out io.Writer) {
+// WriteEscaped writes the data with the characters that have a special
+// meaning in HTML replaced by entities. It is suitable for both text and
+// attribute values.
+func WriteEscaped(data []byte, out io.Writer) {
 	// "Conforming implementations that target HTML don’t need to generate
 	// entities for all the valid named entities that exist, with the exception
 	// of " (&quot;), & (&amp;), < (&lt;) and > (&gt;), which always need to be
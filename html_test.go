@@ -0,0 +1,112 @@
+package commonmark
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+)
+
+type htmlTest struct {
+	input, output string
+}
+
+func runHTMLTests(t *testing.T, converter *Converter, tests []htmlTest) {
+	for _, test := range tests {
+		output, err := converter.ToHTMLBytes([]byte(test.input))
+		if err != nil {
+			t.Errorf("error converting %q: %s", test.input, err)
+		} else if string(output) != test.output {
+			t.Errorf("incorrect output\ninput:\n%s\nexpected output:\n%s\nactual output:\n%s", test.input, test.output, output)
+		}
+	}
+}
+
+func TestHTMLFlavor(t *testing.T) {
+	runHTMLTests(t, New(WithHTMLFlavor(HTML5)), []htmlTest{
+		{"***\n", "<hr>\n"},
+		{"a\\\nb\n", "<p>a<br>\nb</p>\n"},
+		{"a <br/>\n", "<p>a <br/></p>\n"},
+	})
+	runHTMLTests(t, New(WithHTMLFlavor(XHTML)), []htmlTest{
+		{"***\n", "<hr />\n"},
+		{"a\\\nb\n", "<p>a<br />\nb</p>\n"},
+	})
+}
+
+func TestSoftBreaks(t *testing.T) {
+	runHTMLTests(t, New(WithHardWraps()), []htmlTest{
+		{"a\nb\n", "<p>a<br />\nb</p>\n"},
+		{"a  \nb\n", "<p>a<br />\nb</p>\n"},
+	})
+	runHTMLTests(t, New(WithHardWraps(), WithHTMLFlavor(HTML5)), []htmlTest{
+		{"a\nb\n", "<p>a<br>\nb</p>\n"},
+	})
+	runHTMLTests(t, New(WithSoftBreak(" ")), []htmlTest{
+		{"a\nb\n", "<p>a b</p>\n"},
+		{"a\\\nb\n", "<p>a<br />\nb</p>\n"},
+	})
+}
+
+func TestHTMLAttributes(t *testing.T) {
+	runHTMLTests(t, New(WithExtensions(TaskLists)), []htmlTest{
+		{"[a](/u \"b\\\"c\")\n", "<p><a href=\"/u\" title=\"b&quot;c\">a</a></p>\n"},
+		{"- [x] a\n", "<ul>\n<li><input type=\"checkbox\" checked=\"checked\" disabled=\"disabled\" /> a</li>\n</ul>\n"},
+	})
+	runHTMLTests(t, New(WithExtensions(TaskLists), WithHTMLFlavor(HTML5)), []htmlTest{
+		{"- [x] a\n", "<ul>\n<li><input type=\"checkbox\" checked disabled> a</li>\n</ul>\n"},
+	})
+	runHTMLTests(t, New(WithExtensions(TaskLists, Footnotes), WithAttributeQuote(SingleQuotes)), []htmlTest{
+		{"[a](/u \"it's \\\"q\\\"\")\n", "<p><a href='/u' title='it&#39;s &quot;q&quot;'>a</a></p>\n"},
+		{"- [x] a\n", "<ul>\n<li><input type='checkbox' checked='checked' disabled='disabled' /> a</li>\n</ul>\n"},
+		{"```go\n```\n", "<pre><code class='language-go'></code></pre>\n"},
+		{"a[^1] b[^1]\n\n[^1]: c\n", "<p>a<sup class='footnote-ref'><a href='#fn-1' id='fnref-1'>1</a></sup> b<sup class='footnote-ref'><a href='#fn-1' id='fnref-1-2'>1</a></sup></p>\n" +
+			"<section class='footnotes'>\n<ol>\n<li id='fn-1'>\n<p>c <a href='#fnref-1' class='footnote-backref'>↩</a> <a href='#fnref-1-2' class='footnote-backref'>↩<sup class='footnote-ref'>2</sup></a></p>\n</li>\n</ol>\n</section>\n"},
+	})
+}
+
+func TestRenderFuncs(t *testing.T) {
+	converter := New(
+		WithBlockRenderFunc(&Header{}, func(r *HTMLRenderer, b Block, out io.Writer) {
+			h := b.(*Header)
+			fmt.Fprintf(out, "<h%d class=\"title\">", h.Level)
+			r.RenderInline(h.InlineContent, out)
+			fmt.Fprintf(out, "</h%d>\n", h.Level)
+		}),
+		WithInlineRenderFunc(&StringInline{}, func(r *HTMLRenderer, i Inline, out io.Writer) {
+			WriteEscaped(bytes.ToUpper(i.(*StringInline).Content), out)
+		}),
+	)
+	runHTMLTests(t, converter, []htmlTest{
+		{"# a `b` c\n", "<h1 class=\"title\">A <code>b</code> C</h1>\n"},
+		{"x\n\n---\n", "<p>X</p>\n<hr />\n"},
+	})
+}
+
+func TestHTMLRendererOptions(t *testing.T) {
+	doc, err := New().Parse([]byte("a\nb <kbd>c</kbd>\n***\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := NewHTMLRenderer()
+	r.SetHTMLFlavor(HTML5)
+	r.SetHardWraps(true)
+	r.SetHTMLPolicy(NewHTMLPolicy())
+	var out bytes.Buffer
+	r.RenderBlock(doc, &out)
+	expected := "<p>a<br>\nb &lt;kbd&gt;c&lt;/kbd&gt;</p>\n<hr>\n"
+	if out.String() != expected {
+		t.Errorf("incorrect output\nexpected output:\n%s\nactual output:\n%s", expected, out.String())
+	}
+
+	r = NewHTMLRenderer()
+	r.SetSoftBreak(" ")
+	r.SetTagFilter(true)
+	out.Reset()
+	doc, _ = New().Parse([]byte("a\n<xmp>\n"))
+	r.RenderBlock(doc, &out)
+	expected = "<p>a &lt;xmp></p>\n"
+	if out.String() != expected {
+		t.Errorf("incorrect output\nexpected output:\n%s\nactual output:\n%s", expected, out.String())
+	}
+}
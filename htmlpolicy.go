@@ -0,0 +1,205 @@
+package commonmark
+
+import (
+	"io"
+	"regexp"
+	"strings"
+)
+
+// HTMLPolicy is an allowlist of raw HTML tags and attributes. When a Converter
+// is given a policy (see WithHTMLPolicy), every tag in HTML blocks and every
+// inline HTML tag is checked against it. A tag is only passed through if its
+// name is allowed, and all of its attributes are allowed on that tag;
+// otherwise, it is escaped, so that it appears as literal text in the output.
+// A '<' in an HTML block that does not start a tag is escaped too.
+//
+// HTML comments, processing instructions, declarations and CDATA sections are
+// always escaped.
+//
+// Tag and attribute names are matched case-insensitively. The values of
+// attributes that hold URLs, like href and src, must be relative URLs or use
+// one of the schemes http, https and mailto, so that allowing <a href> does
+// not allow javascript: links. Other attribute values are not inspected, so
+// take care when allowing attributes like style.
+//
+// The zero value is not usable; create a policy with NewHTMLPolicy.
+type HTMLPolicy struct {
+	// tags maps lowercase tag names to the set of lowercase attribute names
+	// allowed on that tag.
+	tags map[string]map[string]bool
+	// globalAttrs is the set of lowercase attribute names that are allowed on
+	// every allowed tag.
+	globalAttrs map[string]bool
+}
+
+// NewHTMLPolicy returns a policy that rejects all tags.
+func NewHTMLPolicy() *HTMLPolicy {
+	return &HTMLPolicy{
+		tags:        make(map[string]map[string]bool),
+		globalAttrs: make(map[string]bool),
+	}
+}
+
+// AllowTags allows the given tags, without any attributes other than the ones
+// allowed through AllowAttrs or AllowGlobalAttrs.
+func (p *HTMLPolicy) AllowTags(tags ...string) {
+	for _, tag := range tags {
+		p.attrsFor(tag)
+	}
+}
+
+// AllowAttrs allows the given attributes on the given tag. The tag itself is
+// allowed as well.
+func (p *HTMLPolicy) AllowAttrs(tag string, attrs ...string) {
+	allowed := p.attrsFor(tag)
+	for _, attr := range attrs {
+		allowed[strings.ToLower(attr)] = true
+	}
+}
+
+// AllowGlobalAttrs allows the given attributes on every allowed tag.
+func (p *HTMLPolicy) AllowGlobalAttrs(attrs ...string) {
+	for _, attr := range attrs {
+		p.globalAttrs[strings.ToLower(attr)] = true
+	}
+}
+
+func (p *HTMLPolicy) attrsFor(tag string) map[string]bool {
+	tag = strings.ToLower(tag)
+	allowed := p.tags[tag]
+	if allowed == nil {
+		allowed = make(map[string]bool)
+		p.tags[tag] = allowed
+	}
+	return allowed
+}
+
+// allowsAttr returns whether the policy allows the given attribute with the
+// given value, after entity decoding, on the given tag.
+func (p *HTMLPolicy) allowsAttr(tag, attr string, value []byte) bool {
+	attr = strings.ToLower(attr)
+	if !p.tags[strings.ToLower(tag)][attr] && !p.globalAttrs[attr] {
+		return false
+	}
+	return !urlAttrs[attr] || isSafeURL(value)
+}
+
+// urlAttrs is the set of attributes whose values are URLs.
+var urlAttrs = map[string]bool{
+	"action":     true,
+	"background": true,
+	"cite":       true,
+	"formaction": true,
+	"href":       true,
+	"poster":     true,
+	"src":        true,
+}
+
+// safeURLSchemes is the set of URL schemes that are allowed in the values of
+// urlAttrs.
+var safeURLSchemes = map[string]bool{
+	"http":   true,
+	"https":  true,
+	"mailto": true,
+}
+
+// isSafeURL returns whether the URL is relative or has a scheme in
+// safeURLSchemes. Browsers ignore whitespace and control characters in the
+// scheme, so they are ignored here too. An '&' in the scheme is rejected,
+// because it may be an entity that browsers decode but parseEntity does not,
+// such as &#58 without a semicolon.
+func isSafeURL(url []byte) bool {
+	var scheme []byte
+	for _, c := range url {
+		switch {
+		case c == ':':
+			return safeURLSchemes[strings.ToLower(string(scheme))]
+		case c == '/' || c == '?' || c == '#':
+			return true
+		case c == '&':
+			return false
+		case c > ' ':
+			scheme = append(scheme, c)
+		}
+	}
+	return true
+}
+
+// decodeEntities replaces the entities in an HTML attribute value by the
+// characters they stand for.
+func decodeEntities(data []byte) []byte {
+	var decoded []byte
+	for i := 0; i < len(data); i++ {
+		if data[i] == '&' {
+			if codepoints, length := parseEntity(data[i:]); length > 0 {
+				decoded = append(decoded, codepoints...)
+				i += length - 1
+				continue
+			}
+		}
+		decoded = append(decoded, data[i])
+	}
+	return decoded
+}
+
+var (
+	htmlTagNameRe   = regexp.MustCompile(`^</?(` + htmlTagName + `)`)
+	htmlAttributeRe = regexp.MustCompile(`^\s+(` + htmlAttributeName + `)(?:\s*=\s*(` + htmlAttributeValue + `))?`)
+)
+
+// allowsTag returns whether the given tag, which must match the htmlTag
+// grammar, is allowed by the policy.
+func (p *HTMLPolicy) allowsTag(tag []byte) bool {
+	m := htmlTagNameRe.FindSubmatch(tag)
+	if m == nil {
+		// Comment, processing instruction, declaration or CDATA section.
+		return false
+	}
+	name := string(m[1])
+	if _, ok := p.tags[strings.ToLower(name)]; !ok {
+		return false
+	}
+
+	// Closing tags have no attributes, and the open tag grammar guarantees
+	// that whatever follows the last attribute is harmless.
+	rest := tag[len(m[0]):]
+	for {
+		m := htmlAttributeRe.FindSubmatch(rest)
+		if m == nil {
+			return true
+		}
+		value := m[2]
+		if len(value) > 0 && (value[0] == '"' || value[0] == '\'') {
+			value = value[1 : len(value)-1]
+		}
+		if !p.allowsAttr(name, string(m[1]), decodeEntities(value)) {
+			return false
+		}
+		rest = rest[len(m[0]):]
+	}
+}
+
+// writeFiltered writes the given raw HTML to out, escaping every tag that is
+// not allowed by the policy, as well as every '<' that does not start a tag.
+func (p *HTMLPolicy) writeFiltered(data []byte, out io.Writer) {
+	var start int
+	for i := 0; i < len(data); i++ {
+		if data[i] != '<' {
+			continue
+		}
+		out.Write(data[start:i])
+		if length := htmlTagLength(data[i:]); length == 0 {
+			io.WriteString(out, "&lt;")
+		} else {
+			tag := data[i : i+length]
+			if p.allowsTag(tag) {
+				out.Write(tag)
+			} else {
+				WriteEscaped(tag, out)
+			}
+			i += length - 1
+		}
+		start = i + 1
+	}
+	out.Write(data[start:])
+}
@@ -0,0 +1,35 @@
+package commonmark
+
+import (
+	"testing"
+)
+
+func TestHTMLPolicy(t *testing.T) {
+	policy := NewHTMLPolicy()
+	policy.AllowTags("kbd", "sup")
+	policy.AllowAttrs("details", "open")
+	policy.AllowGlobalAttrs("title")
+	converter := New(WithHTMLPolicy(policy))
+
+	runHTMLTests(t, converter, []htmlTest{
+		{"Press <kbd>Ctrl</kbd>.\n", "<p>Press <kbd>Ctrl</kbd>.</p>\n"},
+		{"x<SUP title='a'>2</SUP>\n", "<p>x<SUP title='a'>2</SUP></p>\n"},
+		{"<kbd onclick=\"f()\">x</kbd>\n", "<p>&lt;kbd onclick=&quot;f()&quot;&gt;x</kbd></p>\n"},
+		{"<script>alert(1)</script>\n", "&lt;script&gt;alert(1)&lt;/script&gt;\n"},
+		{"<details open>\n<summary>x</summary>\n</details>\n", "<p><details open>\n&lt;summary&gt;x&lt;/summary&gt;\n</details></p>\n"},
+		{"<div>a < b</div>\n", "&lt;div&gt;a &lt; b&lt;/div&gt;\n"},
+		{"a <!-- comment -->\n", "<p>a &lt;!-- comment --&gt;</p>\n"},
+	})
+
+	// URL-valued attributes must be relative or use a safe scheme.
+	policy = NewHTMLPolicy()
+	policy.AllowAttrs("a", "href")
+	runHTMLTests(t, New(WithHTMLPolicy(policy)), []htmlTest{
+		{"<a href=\"/x\">a</a> <a href='HTTPS://x.com'>b</a> <a href=mailto:a@b.c>c</a> <a href=\"a/b:c?d:e\">d</a>\n",
+			"<p><a href=\"/x\">a</a> <a href='HTTPS://x.com'>b</a> <a href=mailto:a@b.c>c</a> <a href=\"a/b:c?d:e\">d</a></p>\n"},
+		{"<a href=\"javascript:alert(1)\">x</a>\n", "<p>&lt;a href=&quot;javascript:alert(1)&quot;&gt;x</a></p>\n"},
+		{"<a href=\" JaVa&#115;cript:x\">x</a>\n", "<p>&lt;a href=&quot; JaVa&amp;#115;cript:x&quot;&gt;x</a></p>\n"},
+		{"<a href=\"java&#58alert(1)\">x</a>\n", "<p>&lt;a href=&quot;java&amp;#58alert(1)&quot;&gt;x</a></p>\n"},
+		{"<a href=data:text/html,x>x</a>\n", "<p>&lt;a href=data:text/html,x&gt;x</a></p>\n"},
+	})
+}
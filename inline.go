@@ -3,53 +3,271 @@ package commonmark
 import (
 	"bytes"
 	"fmt"
+	"regexp"
 	"strconv"
+	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
+// Inline represents a piece of inline content, such as a string of text, a
+// code span or a line break. Any type can be used as an Inline.
 type Inline interface {
 }
 
-type stringInline struct {
-	content []byte
+// ParentInline is implemented by inlines that contain other inlines, such as
+// links. Transforms and helpers like PlainText walk the inline tree through
+// it, so custom Inline types with children should implement it.
+type ParentInline interface {
+	// InlineChildren returns the inlines that this one contains, in order.
+	InlineChildren() []Inline
 }
 
-type softLineBreak struct{}
+// StringInline is a string of literal text.
+type StringInline struct {
+	Content []byte
+}
+
+// SoftLineBreak is a line break that is not a hard line break.
+type SoftLineBreak struct{}
+
+// HardLineBreak is a line break that is preceded by two or more spaces or by
+// a backslash.
+type HardLineBreak struct{}
+
+// CodeSpan is a string of literal code.
+type CodeSpan struct {
+	Content []byte
+}
+
+// RawHTML is an inline HTML tag, comment, processing instruction, declaration
+// or CDATA section, which is passed through to the HTML output unchanged.
+type RawHTML struct {
+	Content []byte
+}
+
+// Link is a hyperlink to Destination, with the Children as its link text.
+type Link struct {
+	Destination []byte
+	// Title is the link title, which may be empty.
+	Title    []byte
+	Children []Inline
+	// Attributes holds the link's other HTML attributes; see Attributes.
+	Attributes []Attribute
+}
+
+func (l *Link) InlineChildren() []Inline {
+	return l.Children
+}
+
+// Image is an image with source Destination. Its Children are the image
+// description, which is rendered as the alt text.
+type Image struct {
+	Destination []byte
+	// Title is the image title, which may be empty.
+	Title    []byte
+	Children []Inline
+	// Attributes holds the image's other HTML attributes; see Attributes.
+	Attributes []Attribute
+}
+
+func (i *Image) InlineChildren() []Inline {
+	return i.Children
+}
+
+// MultipleInline is a sequence of inlines.
+type MultipleInline struct {
+	Children []Inline
+}
+
+func (m *MultipleInline) InlineChildren() []Inline {
+	return m.Children
+}
+
+// InlineParser parses a custom type of inline content. InlineParsers are
+// registered for a trigger character (see WithInlineParser), and are only
+// called when the inline parser encounters that character.
+type InlineParser interface {
+	// Parse attempts to parse an Inline at the current position of the
+	// state, which holds the trigger character. It returns the Inline and
+	// the number of bytes it consumed, which must be positive; or nil and 0
+	// if there is no match, in which case the character is parsed as usual.
+	Parse(s InlineParserState) (Inline, int)
+}
 
-type hardLineBreak struct{}
+// InlineParserState is the state of the inline parser, as exposed to
+// InlineParsers.
+type InlineParserState interface {
+	// Data returns the entire text that is being parsed into inlines, e.g.
+	// the content of a paragraph. It must not be modified.
+	Data() []byte
 
-type codeSpan struct {
-	content []byte
+	// Pos returns the current position in Data.
+	Pos() int
+
+	// InLinkText returns whether the current position is inside the text of
+	// a potential link, i.e. after a '[' that can still open a link and
+	// whose matching ']' is followed by a destination. "Links may not
+	// contain other links", so parsers that produce links should not match
+	// there. A stray '[' does not count.
+	InLinkText() bool
 }
 
-type multipleInline struct {
-	children []Inline
+// backtrackingParser is implemented by InlineParsers that can also claim
+// text before the trigger character, such as the local part of an email
+// address before the '@'. Only text that has not yet become part of another
+// Inline can be claimed; pending is its length.
+type backtrackingParser interface {
+	// ParseBacktracking returns the Inline and the number of bytes it
+	// consumed before and after the current position, or nil and zeros if
+	// there is no match.
+	ParseBacktracking(s InlineParserState, pending int) (inline Inline, before, after int)
 }
 
 type inlineParser struct {
+	options     *parseOptions
 	data        []byte
 	pos         int
 	stringStart int
 
-	root *multipleInline
+	root       *MultipleInline
+	delimiters []*delimiter
+	brackets   []*bracket
+	// linkOpeners is computed by InLinkText when it is first needed; see
+	// findLinkOpeners.
+	linkOpeners map[int]bool
+}
+
+// delimiter is an entry on the delimiter stack: a run of delimiter characters
+// that may open or close a construct, which is matched up with its
+// counterpart after the entire content has been parsed.
+type delimiter struct {
+	char     byte
+	length   int
+	inline   *StringInline
+	canOpen  bool
+	canClose bool
+	// rule is the rule for the delimiter, or nil for smart quotes.
+	rule *DelimiterRule
 }
 
-func parseInlines(data []byte) Inline {
+// bracket is an entry on the bracket stack: a '[' or '![' that may open a
+// link or image, once a matching ']' followed by a destination is found.
+type bracket struct {
+	inline *StringInline
+	image  bool
+	// pos is the position of the '[' in the data.
+	pos int
+	// bottom is the size of the delimiter stack when the bracket was pushed;
+	// the delimiters above it belong to the link text.
+	bottom int
+	// active is false for link openers that can no longer form a link,
+	// because a link was formed after them and links cannot contain links.
+	active bool
+}
+
+// DelimiterRule describes a construct that is delimited by runs of the same
+// character on both sides, like ~~strikethrough~~ or ^superscript^. Runs are
+// put on the delimiter stack, and follow the same rules as emphasis: a run can
+// open the construct if it is left-flanking, and close it if it is
+// right-flanking. Matching pairs are replaced by the Inline returned by Wrap.
+// See Converter.AddDelimiterRule.
+type DelimiterRule struct {
+	// Char is the delimiter character.
+	Char byte
+	// MinLength and MaxLength bound the lengths of runs that are delimiters.
+	// Other runs are literal text, unless another rule for the same character
+	// applies to them. An opener only matches a closer of the same length.
+	MinLength, MaxLength int
+	// Wrap returns the Inline for a matched pair of delimiters, given the
+	// inlines between them.
+	Wrap func(children []Inline) Inline
+}
+
+func parseInlines(data []byte, options *parseOptions) Inline {
 	// I can't find where the spec decrees this. But the reference
 	// implementation does it this way:
 	// https://github.com/jgm/CommonMark/blob/67619a5d5c71c44565a9a0413aaf78f9baece528/src/inlines.c#L183
 	data = bytes.TrimRightFunc(data, unicode.IsSpace)
 
 	parser := inlineParser{
-		data: data,
-		root: &multipleInline{},
+		options: options,
+		data:    data,
+		root:    &MultipleInline{},
 	}
 	parser.parse()
 	return parser.root
 }
 
+func (p *inlineParser) Data() []byte {
+	return p.data
+}
+
+func (p *inlineParser) Pos() int {
+	return p.pos
+}
+
+func (p *inlineParser) InLinkText() bool {
+	if p.linkOpeners == nil {
+		p.linkOpeners = findLinkOpeners(p.data)
+	}
+	for _, b := range p.brackets {
+		if b.active && !b.image && p.linkOpeners[b.pos] {
+			return true
+		}
+	}
+	return false
+}
+
+// findLinkOpeners returns the positions of the '[' characters in data whose
+// matching ']' is followed by a link destination, so that they will open a
+// link if they are still active by then. It skips backslash escapes and code
+// spans, but not other inlines that may contain brackets, such as raw HTML,
+// so it is an approximation.
+func findLinkOpeners(data []byte) map[int]bool {
+	openers := make(map[int]bool)
+	var stack []int
+	for i := 0; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '`':
+			numBackticks := 1
+			for i+numBackticks < len(data) && data[i+numBackticks] == '`' {
+				numBackticks++
+			}
+			if closing := backtickStringIndex(data, i+numBackticks, numBackticks); closing != -1 {
+				i = closing
+			}
+			i += numBackticks - 1
+		case '[':
+			stack = append(stack, i)
+		case ']':
+			if len(stack) == 0 {
+				break
+			}
+			if _, _, length := parseLinkTail(data[i+1:]); length > 0 {
+				openers[stack[len(stack)-1]] = true
+			}
+			stack = stack[:len(stack)-1]
+		}
+	}
+	return openers
+}
+
 func (p *inlineParser) parse() {
 	for p.pos < len(p.data) {
+		if inline := p.parseCustomInline(); inline != nil {
+			p.root.Children = append(p.root.Children, inline)
+			continue
+		}
+		if rules := p.options.delimiterRules[p.data[p.pos]]; len(rules) > 0 {
+			if inline := p.parseDelimiterRun(rules); inline != nil {
+				p.root.Children = append(p.root.Children, inline)
+			}
+			continue
+		}
+
 		var inline Inline
 		switch p.data[p.pos] {
 		case '\n':
@@ -75,9 +293,9 @@ func (p *inlineParser) parse() {
 			p.finalizeString()
 
 			if hardBreak {
-				inline = &hardLineBreak{}
+				inline = &HardLineBreak{}
 			} else {
-				inline = &softLineBreak{}
+				inline = &SoftLineBreak{}
 			}
 
 			p.pos = newlinePos + 1
@@ -106,7 +324,7 @@ func (p *inlineParser) parse() {
 			content := p.data[p.pos:closing]
 			content = collapseSpace(bytes.TrimSpace(content))
 
-			inline = &codeSpan{content}
+			inline = &CodeSpan{content}
 			p.pos = closing + numBackticks
 			p.resetString()
 		case '\\':
@@ -119,69 +337,523 @@ func (p *inlineParser) parse() {
 			// "Any ASCII punctuation character may be backslash-escaped."
 			p.finalizeString()
 			p.pos++
-			inline = &stringInline{p.data[p.pos : p.pos+1]}
+			inline = &StringInline{p.data[p.pos : p.pos+1]}
 			p.pos++
 			p.resetString()
-		case '&':
-			// "[A]ll valid HTML entities in any context are recognized as such
-			// and converted into unicode characters before they are stored in
-			// the AST."
-			semicolon := bytes.IndexByte(p.data[p.pos+1:], ';')
-			// "Although HTML5 does accept some entities without a trailing
-			// semicolon (such as &copy), these are not recognized as entities
-			// here, because it makes the grammar too ambiguous."
-			if semicolon < 0 {
+		case '<':
+			// "Text between < and > that looks like an HTML tag is parsed as
+			// a raw HTML tag and will be rendered in HTML without escaping."
+			length := htmlTagLength(p.data[p.pos:])
+			if length == 0 {
 				p.pos++
 				break
 			}
-			semicolon += p.pos + 1
-			entity := string(p.data[p.pos+1 : semicolon])
-			var codepoints string
-
-			if len(entity) > 0 {
-				if entity[0] == '#' {
-					if len(entity) > 1 {
-						if entity[1] == 'x' || entity[1] == 'X' {
-							// "Hexadecimal entities consist of &# + either X or x + a
-							// string of 1-8 hexadecimal digits + ;."
-							if codepoint, err := strconv.ParseUint(entity[2:], 16, 32); err == nil {
-								codepoints = fmt.Sprintf("%c", codepoint)
-							}
-						} else {
-							// "Decimal entities consist of &# + a string of 1–8 arabic
-							// digits + ;. Again, these entities need to be recognised and
-							// tranformed into their corresponding UTF8 codepoints. Invalid
-							// Unicode codepoints will be written as the “unknown
-							// codepoint” character (0xFFFD)."
-							if codepoint, err := strconv.ParseUint(entity[1:], 10, 32); err == nil {
-								codepoints = fmt.Sprintf("%c", codepoint)
-							}
-						}
-					}
-				} else {
-					// "Named entities consist of & + any of the valid HTML5 entity names + ;."
-					codepoints = htmlEntities[entity]
-				}
-			}
 
-			if len(codepoints) == 0 {
+			p.finalizeString()
+			inline = &RawHTML{p.data[p.pos : p.pos+length]}
+			p.pos += length
+			p.resetString()
+		case '&':
+			codepoints, length := parseEntity(p.data[p.pos:])
+			if length == 0 {
 				p.pos++
 				break
 			}
 
 			p.finalizeString()
-			inline = &stringInline{[]byte(codepoints)}
-			p.pos = semicolon + 1
+			inline = &StringInline{codepoints}
+			p.pos += length
 			p.resetString()
+		case '[':
+			p.pushBracket(1, false)
+		case '!':
+			if p.pos+1 >= len(p.data) || p.data[p.pos+1] != '[' {
+				p.pos++
+				break
+			}
+			p.pushBracket(2, true)
+		case ']':
+			inline = p.parseCloseBracket()
+		case '\'', '"', '-', '.':
+			if !p.options.smart {
+				p.pos++
+				break
+			}
+			inline = p.parseSmartPunctuation()
 		default:
 			p.pos++
 		}
 
 		if inline != nil {
-			p.root.children = append(p.root.children, inline)
+			p.root.Children = append(p.root.Children, inline)
+		}
+	}
+	p.finalizeString()
+	p.processDelimiters(0)
+}
+
+// parseCustomInline tries the InlineParsers registered for the character at the
+// current position, in order, and returns the Inline produced by the first one
+// that matches. It returns nil if none of them match.
+func (p *inlineParser) parseCustomInline() Inline {
+	for _, parser := range p.options.inlineParsers[p.data[p.pos]] {
+		if backtracking, ok := parser.parser.(backtrackingParser); ok {
+			pending := p.pos - p.stringStart
+			inline, before, after := backtracking.ParseBacktracking(p, pending)
+			if inline == nil {
+				continue
+			}
+			assertf(before >= 0 && before <= pending && after > 0 && p.pos+after <= len(p.data), "%T consumed %d+%d bytes at position %d of %d", parser.parser, before, after, p.pos, len(p.data))
+			p.pos -= before
+			p.finalizeString()
+			p.pos += before + after
+			p.resetString()
+			return inline
+		}
+		inline, length := parser.parser.Parse(p)
+		if inline == nil {
+			continue
+		}
+		assertf(length > 0 && p.pos+length <= len(p.data), "%T consumed %d bytes at position %d of %d", parser.parser, length, p.pos, len(p.data))
+		p.finalizeString()
+		p.pos += length
+		p.resetString()
+		return inline
+	}
+	return nil
+}
+
+// parseDelimiterRun parses a run of the character at the current position,
+// which has DelimiterRules. If the first rule that applies to the length of
+// the run can open or close, it is pushed onto the delimiter stack; otherwise,
+// it returns nil and the run remains part of the current string.
+func (p *inlineParser) parseDelimiterRun(rules []*DelimiterRule) Inline {
+	c := p.data[p.pos]
+	length := 1
+	for p.pos+length < len(p.data) && p.data[p.pos+length] == c {
+		length++
+	}
+	var rule *DelimiterRule
+	for _, r := range rules {
+		if length >= r.MinLength && length <= r.MaxLength {
+			rule = r
+			break
 		}
 	}
+	if rule == nil {
+		p.pos += length
+		return nil
+	}
+	left, right := p.flanking(p.pos, p.pos+length)
+	if !left && !right {
+		p.pos += length
+		return nil
+	}
+
+	p.finalizeString()
+	inline := &StringInline{p.data[p.pos : p.pos+length]}
+	p.delimiters = append(p.delimiters, &delimiter{
+		char:     c,
+		length:   length,
+		inline:   inline,
+		canOpen:  left,
+		canClose: right,
+		rule:     rule,
+	})
+	p.pos += length
+	p.resetString()
+	return inline
+}
+
+// pushBracket adds the opening bracket of the given length at the current
+// position to the inlines and to the bracket stack.
+func (p *inlineParser) pushBracket(length int, image bool) {
+	p.finalizeString()
+	inline := &StringInline{p.data[p.pos : p.pos+length]}
+	p.root.Children = append(p.root.Children, inline)
+	p.brackets = append(p.brackets, &bracket{
+		inline: inline,
+		image:  image,
+		pos:    p.pos + length - 1,
+		bottom: len(p.delimiters),
+		active: true,
+	})
+	p.pos += length
+	p.resetString()
+}
+
+// parseCloseBracket parses the ']' at the current position. If it closes the
+// topmost bracket on the stack and is followed by a destination and optional
+// title, it replaces the bracket and everything after it by a Link or Image,
+// and returns nil. Otherwise, the ']' remains part of the current string.
+func (p *inlineParser) parseCloseBracket() Inline {
+	if len(p.brackets) == 0 {
+		p.pos++
+		return nil
+	}
+	opener := p.brackets[len(p.brackets)-1]
+	p.brackets = p.brackets[:len(p.brackets)-1]
+	destination, title, length := parseLinkTail(p.data[p.pos+1:])
+	if !opener.active || length == 0 {
+		p.pos++
+		return nil
+	}
+
 	p.finalizeString()
+	start := len(p.root.Children) - 1
+	for p.root.Children[start] != opener.inline {
+		start--
+	}
+	// "The link's text consists of the label (excluding the enclosing square
+	// brackets) parsed as inlines", so delimiters cannot match across the
+	// brackets.
+	outer := p.root
+	p.root = &MultipleInline{append([]Inline(nil), outer.Children[start+1:]...)}
+	p.processDelimiters(opener.bottom)
+	children := p.root.Children
+	p.root = outer
+	p.root.Children = p.root.Children[:start]
+
+	p.pos += 1 + length
+	var attrs []Attribute
+	if p.options.attributes {
+		var attrsLength int
+		attrs, attrsLength = parseAttributeBlock(p.data[p.pos:])
+		p.pos += attrsLength
+	}
+	p.resetString()
+
+	var inline Inline
+	if opener.image {
+		inline = &Image{Destination: destination, Title: title, Children: children, Attributes: attrs}
+	} else {
+		inline = &Link{Destination: destination, Title: title, Children: children, Attributes: attrs}
+		for _, b := range p.brackets {
+			if !b.image {
+				b.active = false
+			}
+		}
+	}
+	return inline
+}
+
+// parseLinkTail parses the part of an inline link after the link text: "a
+// left parenthesis (, optional whitespace, an optional link destination, an
+// optional link title separated from the link destination by whitespace,
+// optional whitespace, and a right parenthesis )". It returns the
+// destination and title, with backslash escapes and entities processed, and
+// the length of the tail, which is 0 if data does not start with one.
+func parseLinkTail(data []byte) (destination, title []byte, length int) {
+	if len(data) == 0 || data[0] != '(' {
+		return nil, nil, 0
+	}
+	i := skipLinkSpace(data, 1)
+
+	destEnd := parseLinkDestination(data[i:])
+	if destEnd < 0 {
+		return nil, nil, 0
+	}
+	destination = data[i : i+destEnd]
+	if len(destination) > 0 && destination[0] == '<' {
+		destination = destination[1 : len(destination)-1]
+	}
+	i += destEnd
+
+	if j := skipLinkSpace(data, i); j > i && j < len(data) {
+		if titleEnd := parseLinkTitle(data[j:]); titleEnd > 0 {
+			title = data[j+1 : j+titleEnd-1]
+			i = j + titleEnd
+		}
+	}
+
+	i = skipLinkSpace(data, i)
+	if i >= len(data) || data[i] != ')' {
+		return nil, nil, 0
+	}
+	return unescapeString(destination), unescapeString(title), i + 1
+}
+
+// skipLinkSpace returns the position of the first non-whitespace character
+// in data at or after i. Whitespace may include one line break.
+func skipLinkSpace(data []byte, i int) int {
+	newline := false
+	for ; i < len(data); i++ {
+		if data[i] == '\n' && !newline {
+			newline = true
+		} else if data[i] != ' ' {
+			break
+		}
+	}
+	return i
+}
+
+// parseLinkDestination returns the length of the link destination at the
+// start of data, which may be 0, or -1 if it is invalid.
+func parseLinkDestination(data []byte) int {
+	if len(data) > 0 && data[0] == '<' {
+		// "a sequence of zero or more characters between an opening < and a
+		// closing > that contains no line breaks or unescaped < or >
+		// characters"
+		for i := 1; i < len(data); i++ {
+			switch data[i] {
+			case '\\':
+				i++
+			case '\n', '<':
+				return -1
+			case '>':
+				return i + 1
+			}
+		}
+		return -1
+	}
+	// "a nonempty sequence of characters that does not include ASCII space or
+	// control characters, and includes parentheses only if (a) they are
+	// backslash-escaped or (b) they are part of a balanced pair of unescaped
+	// parentheses that is not itself inside a balanced pair of unescaped
+	// parentheses"
+	open := false
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; {
+		case c <= ' ':
+			if open {
+				return -1
+			}
+			return i
+		case c == '\\' && i+1 < len(data) && isASCIIPunct(data[i+1]):
+			i++
+		case c == '(':
+			if open {
+				return -1
+			}
+			open = true
+		case c == ')':
+			if !open {
+				return i
+			}
+			open = false
+		}
+	}
+	return -1
+}
+
+// parseLinkTitle returns the length of the link title at the start of data,
+// including its delimiters, or 0 if there is none. "A link title consists of
+// either a sequence of zero or more characters between straight double-quote
+// characters ("), including a " character only if it is backslash-escaped,
+// or [similarly between single quotes], or a sequence of zero or more
+// characters between matching parentheses ((...)), including a ) character
+// only if it is backslash-escaped."
+func parseLinkTitle(data []byte) int {
+	var closer byte
+	switch data[0] {
+	case '"', '\'':
+		closer = data[0]
+	case '(':
+		closer = ')'
+	default:
+		return 0
+	}
+	for i := 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case closer:
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// processDelimiters matches up openers and closers on the delimiter stack
+// above bottom, working from left to right through the potential closers, and
+// then removes them from the stack.
+func (p *inlineParser) processDelimiters(bottom int) {
+	for i := bottom; i < len(p.delimiters); i++ {
+		closer := p.delimiters[i]
+		if !closer.canClose {
+			continue
+		}
+		j := i - 1
+		for j >= bottom && !(p.delimiters[j].canOpen && p.delimiters[j].char == closer.char && p.delimiters[j].length == closer.length) {
+			j--
+		}
+		var opener *delimiter
+		if j >= bottom {
+			opener = p.delimiters[j]
+		}
+
+		if closer.rule == nil {
+			matchQuotes(opener, closer)
+		} else if opener != nil {
+			p.wrapDelimited(opener, closer)
+		}
+
+		if opener != nil {
+			// Delimiters between the opener and the closer can no longer be
+			// matched, so remove them along with the pair itself.
+			p.delimiters = append(p.delimiters[:j], p.delimiters[i+1:]...)
+			i = j - 1
+		}
+	}
+	p.delimiters = p.delimiters[:bottom]
+}
+
+// wrapDelimited replaces the opener, the closer and everything between them
+// by the Inline that the rule produces. Because pairs are matched from the
+// inside out, both delimiters are still direct children of the root.
+func (p *inlineParser) wrapDelimited(opener, closer *delimiter) {
+	children := p.root.Children
+	start, end := -1, -1
+	for i, child := range children {
+		switch child {
+		case opener.inline:
+			start = i
+		case closer.inline:
+			end = i
+		}
+	}
+	assertf(start >= 0 && end > start, "delimiters %q not found in inlines", closer.inline.Content)
+	inner := append([]Inline(nil), children[start+1:end]...)
+	wrapped := append(children[:start:start], closer.rule.Wrap(inner))
+	p.root.Children = append(wrapped, children[end+1:]...)
+}
+
+// flanking returns whether the delimiter run data[start:end] is left-flanking
+// and whether it is right-flanking.
+//
+// "A left-flanking delimiter run is a delimiter run that is (1) not followed
+// by Unicode whitespace, and either (2a) not followed by a Unicode punctuation
+// character, or (2b) followed by a Unicode punctuation character and preceded
+// by Unicode whitespace or a Unicode punctuation character. For purposes of
+// this definition, the beginning and the end of the line count as Unicode
+// whitespace."
+//
+// Right-flanking is defined symmetrically.
+func (p *inlineParser) flanking(start, end int) (left, right bool) {
+	before, after := '\n', '\n'
+	if start > 0 {
+		before, _ = utf8.DecodeLastRune(p.data[:start])
+	}
+	if end < len(p.data) {
+		after, _ = utf8.DecodeRune(p.data[end:])
+	}
+	left = !unicode.IsSpace(after) &&
+		(!isPunct(after) || unicode.IsSpace(before) || isPunct(before))
+	right = !unicode.IsSpace(before) &&
+		(!isPunct(before) || unicode.IsSpace(after) || isPunct(after))
+	return left, right
+}
+
+// isPunct returns whether the rune is an ASCII punctuation character or a
+// Unicode punctuation character.
+func isPunct(r rune) bool {
+	return r < utf8.RuneSelf && isASCIIPunct(byte(r)) || unicode.IsPunct(r)
+}
+
+// The grammar for raw HTML, from http://spec.commonmark.org/0.7/#raw-html
+const (
+	htmlTagName            = `[A-Za-z][A-Za-z0-9]*`
+	htmlAttributeName      = `[a-zA-Z_:][a-zA-Z0-9:._-]*`
+	htmlUnquotedValue      = "[^\"'=<>`\\x00-\\x20]+"
+	htmlSingleQuotedValue  = `'[^']*'`
+	htmlDoubleQuotedValue  = `"[^"]*"`
+	htmlAttributeValue     = `(?:` + htmlUnquotedValue + `|` + htmlSingleQuotedValue + `|` + htmlDoubleQuotedValue + `)`
+	htmlAttributeValueSpec = `(?:\s*=\s*` + htmlAttributeValue + `)`
+	htmlAttribute          = `(?:\s+` + htmlAttributeName + htmlAttributeValueSpec + `?)`
+	htmlOpenTag            = `<` + htmlTagName + htmlAttribute + `*\s*/?>`
+	htmlClosingTag         = `</` + htmlTagName + `\s*>`
+	htmlComment            = `<!--(?:[^-]+|-[^-]+)*-->`
+	htmlProcessingInstr    = `(?s:<\?.*?\?>)`
+	htmlDeclaration        = `<![A-Z]+\s+[^>]*>`
+	htmlCDATA              = `<!\[CDATA\[(?:[^\]]+|\][^\]]|\]\][^>])*\]\]>`
+	htmlTag                = `(?:` + htmlOpenTag + `|` + htmlClosingTag + `|` + htmlComment + `|` + htmlProcessingInstr + `|` + htmlDeclaration + `|` + htmlCDATA + `)`
+)
+
+var htmlTagRe = regexp.MustCompile(`^` + htmlTag)
+
+// htmlTagLength returns the length of the HTML tag at the start of data, or 0
+// if data does not start with an HTML tag.
+func htmlTagLength(data []byte) int {
+	if loc := htmlTagRe.FindIndex(data); loc != nil {
+		return loc[1]
+	}
+	return 0
+}
+
+// parseEntity parses the entity at the start of data, which starts with '&'.
+// It returns the characters that the entity stands for and the length of the
+// entity, or nil and 0 if data does not start with a valid entity.
+//
+// "[A]ll valid HTML entities in any context are recognized as such and
+// converted into unicode characters before they are stored in the AST."
+func parseEntity(data []byte) ([]byte, int) {
+	semicolon := bytes.IndexByte(data, ';')
+	// "Although HTML5 does accept some entities without a trailing semicolon
+	// (such as &copy), these are not recognized as entities here, because it
+	// makes the grammar too ambiguous."
+	if semicolon < 0 {
+		return nil, 0
+	}
+	entity := string(data[1:semicolon])
+	var codepoints string
+
+	if len(entity) > 0 {
+		if entity[0] == '#' {
+			if len(entity) > 1 {
+				if entity[1] == 'x' || entity[1] == 'X' {
+					// "Hexadecimal entities consist of &# + either X or x + a
+					// string of 1-8 hexadecimal digits + ;."
+					if codepoint, err := strconv.ParseUint(entity[2:], 16, 32); err == nil {
+						codepoints = fmt.Sprintf("%c", codepoint)
+					}
+				} else {
+					// "Decimal entities consist of &# + a string of 1–8 arabic
+					// digits + ;. Again, these entities need to be recognised and
+					// tranformed into their corresponding UTF8 codepoints. Invalid
+					// Unicode codepoints will be written as the “unknown
+					// codepoint” character (0xFFFD)."
+					if codepoint, err := strconv.ParseUint(entity[1:], 10, 32); err == nil {
+						codepoints = fmt.Sprintf("%c", codepoint)
+					}
+				}
+			}
+		} else {
+			// "Named entities consist of & + any of the valid HTML5 entity names + ;."
+			codepoints = htmlEntities[entity]
+		}
+	}
+
+	if len(codepoints) == 0 {
+		return nil, 0
+	}
+	return []byte(codepoints), semicolon + 1
+}
+
+// unescapeString processes the backslash escapes and entities in data, which
+// is not parsed as inline content, such as a link destination or the info
+// string of a fenced code block.
+func unescapeString(data []byte) []byte {
+	if bytes.IndexByte(data, '\\') < 0 && bytes.IndexByte(data, '&') < 0 {
+		return data
+	}
+	var out []byte
+	for i := 0; i < len(data); i++ {
+		switch c := data[i]; {
+		case c == '\\' && i+1 < len(data) && isASCIIPunct(data[i+1]):
+			out = append(out, data[i+1])
+			i++
+		case c == '&':
+			if codepoints, length := parseEntity(data[i:]); length > 0 {
+				out = append(out, codepoints...)
+				i += length - 1
+			} else {
+				out = append(out, c)
+			}
+		default:
+			out = append(out, c)
+		}
+	}
+	return out
 }
 
 var asciiPunct = []byte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~")
@@ -224,6 +896,45 @@ func collapseSpace(data []byte) []byte {
 	return out
 }
 
+// walkInlines calls f for i and all of the inlines it contains, in document
+// order. Children are found through the ParentInline interface.
+func walkInlines(i Inline, f func(Inline)) {
+	f(i)
+	if p, ok := i.(ParentInline); ok {
+		for _, child := range p.InlineChildren() {
+			walkInlines(child, f)
+		}
+	}
+}
+
+// PlainText returns the text of the inline and the inlines it contains,
+// without any markup: the content of strings and code spans, with line breaks
+// replaced by spaces. Other inlines, like raw HTML, are left out.
+func PlainText(i Inline) []byte {
+	var text []byte
+	walkInlines(i, func(i Inline) {
+		switch t := i.(type) {
+		case *StringInline:
+			text = append(text, t.Content...)
+		case *CodeSpan:
+			text = append(text, t.Content...)
+		case *SoftLineBreak, *HardLineBreak:
+			text = append(text, ' ')
+		}
+	})
+	return text
+}
+
+// normalizeLabel normalizes a label, such as the label of a link reference
+// definition, for matching: "To normalize a label, perform the unicode case
+// fold and collapse consecutive internal whitespace to a single space." Like
+// the reference implementation, it also strips leading and trailing
+// whitespace.
+func normalizeLabel(label []byte) string {
+	fields := strings.Fields(string(label))
+	return strings.ToUpper(strings.ToLower(strings.Join(fields, " ")))
+}
+
 func (p *inlineParser) resetString() {
 	p.stringStart = p.pos
 }
@@ -233,5 +944,5 @@ func (p *inlineParser) finalizeString() {
 		return
 	}
 	str := p.data[p.stringStart:p.pos]
-	p.root.children = append(p.root.children, &stringInline{str})
+	p.root.Children = append(p.root.Children, &StringInline{str})
 }
@@ -0,0 +1,125 @@
+package commonmark
+
+import (
+	"io"
+	"testing"
+)
+
+func TestSmartPunctuation(t *testing.T) {
+	// Examples taken from the reference implementation's smart_punct.txt.
+	runHTMLTests(t, New(WithSmartPunctuation()), []htmlTest{
+		{"\"Hello,\" said the spider.\n\"'Shelob' is my name.\"\n",
+			"<p>“Hello,” said the spider.\n“‘Shelob’ is my name.”</p>\n"},
+		{"'A', 'B', and 'C' are letters.\n",
+			"<p>‘A’, ‘B’, and ‘C’ are letters.</p>\n"},
+		{"'Oak,' 'elm,' and 'beech' are names of trees.\nSo is 'pine.'\n",
+			"<p>‘Oak,’ ‘elm,’ and ‘beech’ are names of trees.\nSo is ‘pine.’</p>\n"},
+		{"'He said, \"I want to go.\"'\n",
+			"<p>‘He said, “I want to go.”’</p>\n"},
+		{"Were you alive in the 70's?\n",
+			"<p>Were you alive in the 70’s?</p>\n"},
+		{"Here is some quoted '`code`' and a \"<b>tag</b>\".\n",
+			"<p>Here is some quoted ‘<code>code</code>’ and a “<b>tag</b>”.</p>\n"},
+		{"'tis the season to be 'jolly'\n",
+			"<p>’tis the season to be ‘jolly’</p>\n"},
+		{"'We'll use Jane's boat and John's truck,' Jenna said.\n",
+			"<p>‘We’ll use Jane’s boat and John’s truck,’ Jenna said.</p>\n"},
+		{"\"A paragraph with no closing quote.\n\n\"Second paragraph by same speaker, in fiction.\"\n",
+			"<p>“A paragraph with no closing quote.</p>\n<p>“Second paragraph by same speaker, in fiction.”</p>\n"},
+		{"[a]'s b'\n",
+			"<p>[a]’s b’</p>\n"},
+		{"\\\"This is not smart.\\\"\nThis isn't either.\n5\\'8\\\"\n",
+			"<p>&quot;This is not smart.&quot;\nThis isn’t either.\n5'8&quot;</p>\n"},
+		{"Some dashes:  em---em\nen--en\nem --- em\nen -- en\n2--3\n",
+			"<p>Some dashes:  em—em\nen–en\nem — em\nen – en\n2–3</p>\n"},
+		{"one-\ntwo--\nthree---\nfour----\nfive-----\nsix------\nseven-------\neight--------\nnine---------\nthirteen-------------.\n",
+			"<p>one-\ntwo–\nthree—\nfour––\nfive—–\nsix——\nseven—––\neight––––\nnine———\nthirteen———––.</p>\n"},
+		{"Escaped hyphens: \\-- \\-\\-\\-.\n",
+			"<p>Escaped hyphens: -- ---.</p>\n"},
+		{"Ellipses...and...and....\n",
+			"<p>Ellipses…and…and….</p>\n"},
+		{"No ellipses\\.\\.\\.\n",
+			"<p>No ellipses...</p>\n"},
+		{"    \"code\" -- ...\n",
+			"<pre><code>&quot;code&quot; -- ...\n</code></pre>\n"},
+	})
+}
+
+// mention is a custom inline: an @ followed by a user name.
+type mention struct {
+	user []byte
+}
+
+type mentionParser struct{}
+
+func (mentionParser) Parse(s InlineParserState) (Inline, int) {
+	data, pos := s.Data(), s.Pos()
+	if pos > 0 && isASCIIAlphanumeric(data[pos-1]) {
+		return nil, 0
+	}
+	end := pos + 1
+	for end < len(data) && isASCIIAlphanumeric(data[end]) {
+		end++
+	}
+	if end == pos+1 {
+		return nil, 0
+	}
+	return &mention{data[pos+1 : end]}, end - pos
+}
+
+func isASCIIAlphanumeric(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+func TestInlineParsers(t *testing.T) {
+	converter := New(
+		WithInlineParser('@', mentionParser{}),
+		WithInlineRenderFunc(&mention{}, func(r *HTMLRenderer, i Inline, out io.Writer) {
+			io.WriteString(out, "<a href=\"/users/")
+			WriteEscaped(i.(*mention).user, out)
+			io.WriteString(out, "\">@")
+			WriteEscaped(i.(*mention).user, out)
+			io.WriteString(out, "</a>")
+		}),
+	)
+	runHTMLTests(t, converter, []htmlTest{
+		{"hi @bob!\n", "<p>hi <a href=\"/users/bob\">@bob</a>!</p>\n"},
+		{"bob@example.com @ @\n", "<p>bob@example.com @ @</p>\n"},
+		{"`@bob` \\@bob\n", "<p><code>@bob</code> @bob</p>\n"},
+	})
+}
+
+func TestLinks(t *testing.T) {
+	runHTMLTests(t, New(), []htmlTest{
+		{"[link](/uri \"title\")\n", "<p><a href=\"/uri\" title=\"title\">link</a></p>\n"},
+		{"[link](/uri)\n", "<p><a href=\"/uri\">link</a></p>\n"},
+		{"[link]()\n", "<p><a href=\"\">link</a></p>\n"},
+		{"[link](<>)\n", "<p><a href=\"\">link</a></p>\n"},
+		{"[link](/my uri)\n", "<p>[link](/my uri)</p>\n"},
+		{"[link](</my uri>)\n", "<p><a href=\"/my%20uri\">link</a></p>\n"},
+		{"[link](foo\nbar)\n", "<p>[link](foo\nbar)</p>\n"},
+		{"[link]((foo)and(bar))\n", "<p><a href=\"(foo)and(bar)\">link</a></p>\n"},
+		{"[link](foo(and(bar)))\n", "<p>[link](foo(and(bar)))</p>\n"},
+		{"[link](foo\\)\\:)\n", "<p><a href=\"foo):\">link</a></p>\n"},
+		{"[link](foo%20b&auml;)\n", "<p><a href=\"foo%20b%C3%A4\">link</a></p>\n"},
+		{"[link](/url \"title\")\n[link](/url 'title')\n[link](/url (title))\n", "<p><a href=\"/url\" title=\"title\">link</a>\n<a href=\"/url\" title=\"title\">link</a>\n<a href=\"/url\" title=\"title\">link</a></p>\n"},
+		{"[link](/url \"title \\\"&quot;\")\n", "<p><a href=\"/url\" title=\"title &quot;&quot;\">link</a></p>\n"},
+		{"[link](   /uri\n  \"title\"  )\n", "<p><a href=\"/uri\" title=\"title\">link</a></p>\n"},
+		{"[link] (/uri)\n", "<p>[link] (/uri)</p>\n"},
+		{"[foo <bar attr=\"](baz)\">\n", "<p>[foo <bar attr=\"](baz)\"></p>\n"},
+		{"[a [b](/b) c](/a)\n", "<p>[a <a href=\"/b\">b</a> c](/a)</p>\n"},
+		{"[`code](/u)`\n", "<p>[<code>code](/u)</code></p>\n"},
+	})
+}
+
+func TestImages(t *testing.T) {
+	runHTMLTests(t, New(), []htmlTest{
+		{"![foo](/url \"title\")\n", "<p><img src=\"/url\" alt=\"foo\" title=\"title\" /></p>\n"},
+		{"![foo](train.jpg)\n", "<p><img src=\"train.jpg\" alt=\"foo\" /></p>\n"},
+		{"My ![foo bar](/path/to/train.jpg  \"title\"   )\n", "<p>My <img src=\"/path/to/train.jpg\" alt=\"foo bar\" title=\"title\" /></p>\n"},
+		{"![foo](<url>)\n", "<p><img src=\"url\" alt=\"foo\" /></p>\n"},
+		{"![](/url)\n", "<p><img src=\"/url\" alt=\"\" /></p>\n"},
+		{"[![moon](moon.jpg)](/uri)\n", "<p><a href=\"/uri\"><img src=\"moon.jpg\" alt=\"moon\" /></a></p>\n"},
+		{"!\\[foo](/url)\n", "<p>![foo](/url)</p>\n"},
+	})
+}
@@ -0,0 +1,178 @@
+package commonmark
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Math is an Extension that adds support for TeX math, as used by Pandoc and
+// GitHub:
+//
+//   - $…$ is inline math. The opening $ must not be followed by whitespace,
+//     and the closing $ must not be preceded by whitespace or followed by a
+//     digit, so that amounts like $5 and $10 stay literal text.
+//   - $$…$$ within a paragraph is display math.
+//   - A line consisting of $$ starts a block of display math, which ends at
+//     the next such line, like a fenced code block. So does a fenced code
+//     block with the info string math.
+//
+// Like the content of code spans, math is taken literally: there is no
+// emphasis, and backslashes are not escapes. In HTML, the TeX is wrapped in
+// <span class="math inline">, <span class="math display"> or
+// <div class="math display">, ready to be typeset by KaTeX or MathJax in the
+// browser.
+var Math Extension = mathExtension{}
+
+type mathExtension struct{}
+
+func (mathExtension) Extend(c *Converter) {
+	c.AddBlockParser(mathBlockParser{}, 260)
+	c.AddInlineParser('$', mathInlineParser{}, 0)
+	c.AddTransform(AfterBlockParsing, convertMathCodeBlocks, 0)
+	c.Renderer().SetBlockFunc(&MathBlock{}, renderMathBlock)
+	c.Renderer().SetInlineFunc(&MathInline{}, renderMathInline)
+}
+
+// MathBlock is a block of display math. Its Content holds the TeX source.
+type MathBlock struct {
+	BaseBlock
+
+	// closed is set once the closing $$ line has been seen.
+	closed bool
+}
+
+func (m *MathBlock) AcceptsLines() bool {
+	return true
+}
+
+func (m *MathBlock) AcceptsLiteralLines() bool {
+	return true
+}
+
+// MathInline is math within a paragraph.
+type MathInline struct {
+	// Content is the TeX source.
+	Content []byte
+	// Display is true for display math ($$…$$), and false for inline math
+	// ($…$).
+	Display bool
+}
+
+var mathBlockFenceRe = regexp.MustCompile(`^ {0,3}\$\$ *\n$`)
+
+type mathBlockParser struct{}
+
+func (mathBlockParser) Start(s BlockParserState, line []byte) ([]byte, bool) {
+	if !mathBlockFenceRe.Match(line) {
+		return nil, false
+	}
+	s.AddBlock(&MathBlock{}, mathBlockParser{})
+	return nil, true
+}
+
+func (mathBlockParser) Continue(b Block, line []byte) ([]byte, bool) {
+	block := b.(*MathBlock)
+	if block.closed {
+		return nil, false
+	}
+	if mathBlockFenceRe.Match(line) {
+		block.closed = true
+		return nil, true
+	}
+	return line, true
+}
+
+func (mathBlockParser) Close(b Block) {}
+
+// convertMathCodeBlocks is a Transform that replaces fenced code blocks with
+// the language math by MathBlocks.
+func convertMathCodeBlocks(doc *Document) error {
+	walkBlocks(doc, func(b Block) {
+		children := b.Children()
+		for i, child := range children {
+			if code, ok := child.(*FencedCodeBlock); ok && string(code.Language()) == "math" {
+				children[i] = &MathBlock{BaseBlock: BaseBlock{Content: code.Content}}
+			}
+		}
+	})
+	return nil
+}
+
+type mathInlineParser struct{}
+
+func (mathInlineParser) Parse(s InlineParserState) (Inline, int) {
+	data, pos := s.Data(), s.Pos()
+	delimiter := 1
+	for pos+delimiter < len(data) && data[pos+delimiter] == '$' {
+		delimiter++
+	}
+	if delimiter > 2 {
+		return nil, 0
+	}
+	display := delimiter == 2
+	start := pos + delimiter
+	if start >= len(data) {
+		return nil, 0
+	}
+	if r, _ := utf8.DecodeRune(data[start:]); !display && unicode.IsSpace(r) {
+		return nil, 0
+	}
+
+	for end := start + 1; end < len(data); end++ {
+		if !bytes.HasPrefix(data[end:], []byte("$$")[:delimiter]) {
+			continue
+		}
+		// A dollar sign after an odd number of backslashes is escaped; after
+		// an even number, the backslashes escape each other, like the TeX
+		// line break \\.
+		backslashes := 0
+		for end-backslashes > start && data[end-backslashes-1] == '\\' {
+			backslashes++
+		}
+		if backslashes%2 == 1 {
+			continue
+		}
+		after := end + delimiter
+		if after < len(data) && data[after] == '$' {
+			// A longer run of dollar signs is not a closer; skip it.
+			for end+1 < len(data) && data[end+1] == '$' {
+				end++
+			}
+			continue
+		}
+		if !display {
+			if r, _ := utf8.DecodeLastRune(data[:end]); unicode.IsSpace(r) {
+				continue
+			}
+			if after < len(data) && data[after] >= '0' && data[after] <= '9' {
+				continue
+			}
+		}
+		return &MathInline{Content: data[start:end], Display: display}, after - pos
+	}
+	return nil, 0
+}
+
+func renderMathBlock(r *HTMLRenderer, b Block, out io.Writer) {
+	io.WriteString(out, "<div")
+	r.WriteAttr("class", []byte("math display"), out)
+	io.WriteString(out, ">")
+	WriteEscaped(bytes.TrimRight(b.(*MathBlock).Content, "\n"), out)
+	io.WriteString(out, "</div>\n")
+}
+
+func renderMathInline(r *HTMLRenderer, i Inline, out io.Writer) {
+	math := i.(*MathInline)
+	io.WriteString(out, "<span")
+	if math.Display {
+		r.WriteAttr("class", []byte("math display"), out)
+	} else {
+		r.WriteAttr("class", []byte("math inline"), out)
+	}
+	io.WriteString(out, ">")
+	WriteEscaped(math.Content, out)
+	io.WriteString(out, "</span>")
+}
@@ -0,0 +1,30 @@
+package commonmark
+
+import (
+	"testing"
+)
+
+func TestMath(t *testing.T) {
+	runHTMLTests(t, New(WithExtensions(Math)), []htmlTest{
+		{"$x^2$ and $a*b*c$\n", "<p><span class=\"math inline\">x^2</span> and <span class=\"math inline\">a*b*c</span></p>\n"},
+		{"Euler: $$e^{i\\pi} + 1 = 0$$\n", "<p>Euler: <span class=\"math display\">e^{i\\pi} + 1 = 0</span></p>\n"},
+		{"$a < b \\_ c$\n", "<p><span class=\"math inline\">a &lt; b \\_ c</span></p>\n"},
+		{"$5 and $10\n", "<p>$5 and $10</p>\n"},
+		{"$ x$ and $x $\n", "<p>$ x$ and $x $</p>\n"},
+		{"$x$1 $y$\n", "<p><span class=\"math inline\">x$1 $y</span></p>\n"},
+		{"\\$x$ and $\\$$\n", "<p>$x$ and <span class=\"math inline\">\\$</span></p>\n"},
+		{"$a\\$b$\n", "<p><span class=\"math inline\">a\\$b</span></p>\n"},
+		{"$a\\\\$ b\n", "<p><span class=\"math inline\">a\\\\</span> b</p>\n"},
+		{"$a\\\\\\$b$\n", "<p><span class=\"math inline\">a\\\\\\$b</span></p>\n"},
+		{"`$x$` $`y`$\n", "<p><code>$x$</code> <span class=\"math inline\">`y`</span></p>\n"},
+		{"$$\n\\int_0^1 x\\,dx\n  < 1\n$$\nText\n", "<div class=\"math display\">\\int_0^1 x\\,dx\n  &lt; 1</div>\n<p>Text</p>\n"},
+		{"Text\n$$\nx\n$$\n", "<p>Text</p>\n<div class=\"math display\">x</div>\n"},
+		{"> $$\n> x\n\ny\n", "<blockquote>\n<div class=\"math display\">x</div>\n</blockquote>\n<p>y</p>\n"},
+		{"```math\nx *y*\n```\n", "<div class=\"math display\">x *y*</div>\n"},
+		{"- ```math\n  x\n  ```\n", "<ul>\n<li><div class=\"math display\">x</div></li>\n</ul>\n"},
+	})
+	runHTMLTests(t, New(), []htmlTest{
+		{"$x$\n", "<p>$x$</p>\n"},
+		{"```math\nx\n```\n", "<pre><code class=\"language-math\">x\n</code></pre>\n"},
+	})
+}
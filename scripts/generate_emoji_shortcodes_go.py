@@ -0,0 +1,44 @@
+#!/usr/bin/python
+
+# Generates emoji_shortcodes.go from the emoji database of GitHub's gemoji
+# project, or from a local file in the same format given as the argument.
+#
+# The checked-in table is generated from scripts/emoji_subset.json, a
+# hand-picked subset of gemoji's commonly used emoji, to keep the binary
+# small:
+#
+#   scripts/generate_emoji_shortcodes_go.py scripts/emoji_subset.json | gofmt > emoji_shortcodes.go
+#
+# Without an argument, the full database is downloaded instead.
+
+import json
+import sys
+
+try:
+    from urllib2 import urlopen
+except ImportError:
+    from urllib.request import urlopen
+
+if len(sys.argv) > 1:
+    data = open(sys.argv[1], 'rb').read()
+else:
+    data = urlopen('https://raw.githubusercontent.com/github/gemoji/master/db/emoji.json').read()
+emojis = json.loads(data.decode('utf-8'))
+
+shortcodes = {}
+for emoji in emojis:
+    if 'emoji' not in emoji:
+        continue
+    for alias in emoji['aliases']:
+        shortcodes[alias] = emoji['emoji']
+
+out = getattr(sys.stdout, 'buffer', sys.stdout)
+out.write(b'package commonmark\n')
+out.write(b'\n')
+command = ' '.join(['scripts/generate_emoji_shortcodes_go.py'] + sys.argv[1:])
+out.write(('// Autogenerated by %s; do not edit.\n' % command).encode('utf-8'))
+out.write(b'var emojiShortcodes = map[string]string{\n')
+for key, value in sorted(shortcodes.items()):
+    line = u'\t"%s": "%s",\n' % (key, value)
+    out.write(line.encode('utf-8'))
+out.write(b'}\n')
@@ -0,0 +1,119 @@
+package commonmark
+
+import (
+	"bytes"
+)
+
+// Replacements made by smart punctuation.
+const (
+	leftSingleQuote  = "‘"
+	rightSingleQuote = "’"
+	leftDoubleQuote  = "“"
+	rightDoubleQuote = "”"
+	enDash           = "–"
+	emDash           = "—"
+	ellipsis         = "…"
+)
+
+// parseSmartPunctuation parses a quote, a run of hyphens or a run of periods
+// at the current position. If there is nothing to replace, it returns nil and
+// the characters remain part of the current string.
+func (p *inlineParser) parseSmartPunctuation() Inline {
+	c := p.data[p.pos]
+	length := 1
+	for p.pos+length < len(p.data) && p.data[p.pos+length] == c {
+		length++
+	}
+
+	var inline *StringInline
+	switch c {
+	case '\'', '"':
+		// Quotes are never part of a longer run; each is a delimiter of its
+		// own.
+		length = 1
+		left, right := p.flanking(p.pos, p.pos+1)
+		// Like the reference implementation, we don't let a quote directly
+		// after a link or parenthesized text open, as in [foo]'s or (bar)'s.
+		afterBracket := p.pos > 0 && (p.data[p.pos-1] == ']' || p.data[p.pos-1] == ')')
+		canOpen := left && !right && !afterBracket
+		canClose := right
+
+		// These are the replacements for quotes that are not paired up. If a
+		// pair is found later, processDelimiters replaces them.
+		content := rightSingleQuote
+		if c == '"' {
+			if canClose {
+				content = rightDoubleQuote
+			} else {
+				content = leftDoubleQuote
+			}
+		}
+		inline = &StringInline{[]byte(content)}
+		if canOpen || canClose {
+			p.delimiters = append(p.delimiters, &delimiter{
+				char:     c,
+				length:   1,
+				inline:   inline,
+				canOpen:  canOpen,
+				canClose: canClose,
+			})
+		}
+	case '-':
+		if length < 2 {
+			p.pos++
+			return nil
+		}
+		inline = &StringInline{dashes(length)}
+	case '.':
+		if length < 3 {
+			p.pos += length
+			return nil
+		}
+		// Longer runs are replaced three periods at a time.
+		length = 3
+		inline = &StringInline{[]byte(ellipsis)}
+	}
+
+	p.finalizeString()
+	p.pos += length
+	p.resetString()
+	return inline
+}
+
+// dashes returns the replacement for a run of two or more hyphens. Like the
+// reference implementation, it uses only em dashes if the length is divisible
+// by 3, only en dashes if it is divisible by 2, and otherwise as many em
+// dashes as possible followed by one or two en dashes.
+func dashes(length int) []byte {
+	var em, en int
+	switch {
+	case length%3 == 0:
+		em = length / 3
+	case length%2 == 0:
+		en = length / 2
+	case length%3 == 2:
+		em = (length - 2) / 3
+		en = 1
+	default:
+		em = (length - 4) / 3
+		en = 2
+	}
+	return append(bytes.Repeat([]byte(emDash), em), bytes.Repeat([]byte(enDash), en)...)
+}
+
+// matchQuotes replaces the contents of a quote closer, and of its opener if
+// one was found, by the corresponding curly quotes.
+func matchQuotes(opener, closer *delimiter) {
+	switch closer.char {
+	case '\'':
+		closer.inline.Content = []byte(rightSingleQuote)
+		if opener != nil {
+			opener.inline.Content = []byte(leftSingleQuote)
+		}
+	case '"':
+		closer.inline.Content = []byte(rightDoubleQuote)
+		if opener != nil {
+			opener.inline.Content = []byte(leftDoubleQuote)
+		}
+	}
+}
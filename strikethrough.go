@@ -0,0 +1,59 @@
+package commonmark
+
+import (
+	"io"
+)
+
+// Strikethrough is an Extension that adds support for strikethrough, as
+// specified by GitHub Flavored Markdown:
+// https://github.github.com/gfm/#strikethrough-extension-
+//
+// "Strikethrough text is any text wrapped in a matching pair of one or two
+// tildes (~)."
+//
+// The tildes follow the same rules as emphasis delimiters: a run of tildes
+// can open strikethrough if it is left-flanking, and close it if it is
+// right-flanking. Runs of three or more tildes are literal text.
+var Strikethrough Extension = strikethroughExtension{}
+
+// DoubleTildeStrikethrough is like Strikethrough, but only recognizes pairs of
+// two tildes, so that single tildes, like in ~/.profile or ~5 minutes, are
+// always literal text.
+var DoubleTildeStrikethrough Extension = strikethroughExtension{doubleTildeOnly: true}
+
+type strikethroughExtension struct {
+	doubleTildeOnly bool
+}
+
+func (e strikethroughExtension) Extend(c *Converter) {
+	rule := DelimiterRule{
+		Char:      '~',
+		MinLength: 1,
+		MaxLength: 2,
+		Wrap: func(children []Inline) Inline {
+			return &StrikethroughInline{children}
+		},
+	}
+	if e.doubleTildeOnly {
+		rule.MinLength = 2
+	}
+	c.AddDelimiterRule(rule)
+	c.Renderer().SetInlineFunc(&StrikethroughInline{}, renderStrikethroughInline)
+}
+
+// StrikethroughInline is text that has been struck through.
+type StrikethroughInline struct {
+	Children []Inline
+}
+
+func (s *StrikethroughInline) InlineChildren() []Inline {
+	return s.Children
+}
+
+func renderStrikethroughInline(r *HTMLRenderer, i Inline, out io.Writer) {
+	io.WriteString(out, "<del>")
+	for _, child := range i.(*StrikethroughInline).Children {
+		r.RenderInline(child, out)
+	}
+	io.WriteString(out, "</del>")
+}
@@ -0,0 +1,26 @@
+package commonmark
+
+import (
+	"testing"
+)
+
+func TestStrikethrough(t *testing.T) {
+	runHTMLTests(t, New(WithExtensions(Strikethrough)), []htmlTest{
+		{"~~Hi~~ Hello, world!\n", "<p><del>Hi</del> Hello, world!</p>\n"},
+		{"This ~text~ is ~~curious~~.\n", "<p>This <del>text</del> is <del>curious</del>.</p>\n"},
+		{"This will ~~~not~~~ strike.\n", "<p>This will ~~~not~~~ strike.</p>\n"},
+		{"This ~~has a\n\nnew paragraph~~.\n", "<p>This ~~has a</p>\n<p>new paragraph~~.</p>\n"},
+		{"~~a ~b~ c~~\n", "<p><del>a <del>b</del> c</del></p>\n"},
+		{"~~a~ b~~\n", "<p><del>a~ b</del></p>\n"},
+		{"a ~~ b ~~ c\n", "<p>a ~~ b ~~ c</p>\n"},
+		{"`~~code~~` and ~~`code`~~\n", "<p><code>~~code~~</code> and <del><code>code</code></del></p>\n"},
+		{"<a href=\"x~~y~~\">~~z~~</a>\n", "<p><a href=\"x~~y~~\"><del>z</del></a></p>\n"},
+		{"\\~~a~~\n", "<p>~~a~~</p>\n"},
+	})
+	runHTMLTests(t, New(WithExtensions(DoubleTildeStrikethrough)), []htmlTest{
+		{"~a~ ~~b~~\n", "<p>~a~ <del>b</del></p>\n"},
+	})
+	runHTMLTests(t, New(WithExtensions(Strikethrough), WithSmartPunctuation()), []htmlTest{
+		{"\"~~a~~\" ~~'b'~~\n", "<p>“<del>a</del>” <del>‘b’</del></p>\n"},
+	})
+}
@@ -0,0 +1,267 @@
+package commonmark
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+)
+
+// Tables is an Extension that adds support for tables, as specified by GitHub
+// Flavored Markdown: https://github.github.com/gfm/#tables-extension-
+//
+// "A table is an arrangement of data with rows and columns, consisting of a
+// single header row, a delimiter row separating the header from the data, and
+// zero or more data rows."
+var Tables Extension = tablesExtension{}
+
+type tablesExtension struct{}
+
+func (tablesExtension) Extend(c *Converter) {
+	// The delimiter row is only recognized below a paragraph line, and must
+	// not be mistaken for a setext header underline or a horizontal rule.
+	c.AddBlockParser(tableParser{}, 700)
+	c.Renderer().SetBlockFunc(&Table{}, renderTable)
+	c.Renderer().SetBlockFunc(&TableRow{}, renderTableRow)
+	c.Renderer().SetBlockFunc(&TableCell{}, renderTableCell)
+}
+
+// TableAlignment is the alignment of the cells in a table column.
+type TableAlignment int
+
+const (
+	// AlignNone means that the delimiter row does not specify an alignment.
+	AlignNone TableAlignment = iota
+	// AlignLeft is specified by a delimiter cell like :--.
+	AlignLeft
+	// AlignCenter is specified by a delimiter cell like :-:.
+	AlignCenter
+	// AlignRight is specified by a delimiter cell like --:.
+	AlignRight
+)
+
+// Table is a table. Its children are TableRows, the first of which is the
+// header row.
+type Table struct {
+	BaseBlock
+	// Alignments holds the alignment of each column. Its length is the number
+	// of columns, which is determined by the header row.
+	Alignments []TableAlignment
+}
+
+// AppendLine parses the line as a data row, and appends it to the table.
+func (t *Table) AppendLine(line []byte) {
+	t.AppendChild(t.parseRow(line, false))
+}
+
+func (t *Table) AcceptsLines() bool {
+	return true
+}
+
+// parseRow parses a line into a TableRow with exactly one cell for each
+// column.
+//
+// "The remainder of the table’s rows may vary in the number of cells. If a
+// number of cells fewer than the number of cells in the header row, empty
+// cells are inserted. If greater, the excess is ignored."
+func (t *Table) parseRow(line []byte, header bool) *TableRow {
+	row := &TableRow{Header: header}
+	cells := splitTableRow(line)
+	for i, alignment := range t.Alignments {
+		cell := &TableCell{Header: header, Alignment: alignment}
+		if i < len(cells) {
+			cell.Content = cells[i]
+		}
+		row.AppendChild(cell)
+	}
+	return row
+}
+
+// TableRow is a row of a table. Its children are TableCells.
+type TableRow struct {
+	BaseBlock
+	// Header is true for the header row.
+	Header bool
+}
+
+// TableCell is a cell of a table, which contains inline content.
+type TableCell struct {
+	BaseBlock
+	// Header is true for cells in the header row.
+	Header bool
+	// Alignment is the alignment of the cell's column.
+	Alignment TableAlignment
+}
+
+func (c *TableCell) RawInlineContent() []byte {
+	return c.Content
+}
+
+func (c *TableCell) SetInlineContent(inline Inline) {
+	c.InlineContent = inline
+}
+
+func (c *TableCell) ParsedInlineContent() Inline {
+	return c.InlineContent
+}
+
+type tableParser struct{}
+
+// Start recognizes a delimiter row, and turns the last line of the open
+// paragraph into the header row.
+func (tableParser) Start(s BlockParserState, line []byte) ([]byte, bool) {
+	par, isParagraph := s.OpenBlock().(*Paragraph)
+	if !isParagraph {
+		return nil, false
+	}
+	alignments := parseTableDelimiterRow(line)
+	if alignments == nil {
+		return nil, false
+	}
+
+	// The paragraph content ends in a newline; find the start of the last
+	// line before it.
+	content := par.Content
+	lastLineStart := bytes.LastIndexByte(content[:len(content)-1], '\n') + 1
+	headerLine := content[lastLineStart:]
+	// "The header row must match the delimiter row in the number of cells.
+	// If not, a table will not be recognized."
+	if len(splitTableRow(headerLine)) != len(alignments) {
+		return nil, false
+	}
+
+	table := &Table{Alignments: alignments}
+	table.AppendChild(table.parseRow(headerLine, true))
+	if lastLineStart == 0 {
+		s.ReplaceOpenBlock(table, tableParser{})
+	} else {
+		// The preceding lines remain a paragraph, which is closed by adding
+		// the table as its sibling. The open paragraph itself is left
+		// alone, because Start must not modify anything unless it goes
+		// through the state.
+		s.ReplaceOpenBlock(&Paragraph{BaseBlock: BaseBlock{Content: content[:lastLineStart]}}, paragraphParser{})
+		s.AddBlock(table, tableParser{})
+	}
+	return nil, true
+}
+
+// Continue continues the table on any nonblank line. Lines that start another
+// type of block end the table, because the table can't contain that block.
+//
+// "The table is broken at the first empty line, or beginning of another
+// block-level structure."
+func (tableParser) Continue(b Block, line []byte) ([]byte, bool) {
+	return line, !isBlank(line)
+}
+
+func (tableParser) Close(b Block) {}
+
+// "The delimiter row consists of cells whose only content are hyphens (-),
+// and optionally, a leading or trailing colon (:), or both, to indicate left,
+// right, or center alignment respectively."
+var (
+	tableDelimiterRowRe  = regexp.MustCompile(`^ {0,3}\|?` + tableDelimiterCell + `(?:\|` + tableDelimiterCell + `)*\|? *\n$`)
+	tableDelimiterCellRe = regexp.MustCompile(tableDelimiterCell)
+)
+
+const tableDelimiterCell = ` *(:?)-+(:?) *`
+
+// parseTableDelimiterRow returns the column alignments specified by the
+// delimiter row, or nil if the line is not a delimiter row.
+func parseTableDelimiterRow(line []byte) []TableAlignment {
+	if !tableDelimiterRowRe.Match(line) {
+		return nil
+	}
+	var alignments []TableAlignment
+	for _, m := range tableDelimiterCellRe.FindAllSubmatch(line, -1) {
+		left, right := len(m[1]) > 0, len(m[2]) > 0
+		switch {
+		case left && right:
+			alignments = append(alignments, AlignCenter)
+		case left:
+			alignments = append(alignments, AlignLeft)
+		case right:
+			alignments = append(alignments, AlignRight)
+		default:
+			alignments = append(alignments, AlignNone)
+		}
+	}
+	return alignments
+}
+
+// splitTableRow splits a row into the raw contents of its cells.
+//
+// "Each row consists of cells containing arbitrary text, in which inlines are
+// parsed, separated by pipes (|). A leading and trailing pipe is also
+// recommended for clarity of reading, and if there’s otherwise parsing
+// ambiguity. Spaces between pipes and cell content are trimmed."
+//
+// "It is possible to include a pipe in a cell’s content by escaping it,
+// including inside other inline spans". The escaping backslash is removed
+// here, before the cell content is parsed as inlines.
+func splitTableRow(line []byte) [][]byte {
+	line = bytes.TrimSpace(line)
+	if len(line) > 0 && line[0] == '|' {
+		line = line[1:]
+	}
+	if len(line) > 0 && line[len(line)-1] == '|' && (len(line) < 2 || line[len(line)-2] != '\\') {
+		line = line[:len(line)-1]
+	}
+
+	var cells [][]byte
+	var cell []byte
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '\\' && i+1 < len(line) && line[i+1] == '|':
+			cell = append(cell, '|')
+			i++
+		case line[i] == '|':
+			cells = append(cells, bytes.TrimSpace(cell))
+			cell = nil
+		default:
+			cell = append(cell, line[i])
+		}
+	}
+	return append(cells, bytes.TrimSpace(cell))
+}
+
+func renderTable(r *HTMLRenderer, b Block, out io.Writer) {
+	rows := b.Children()
+	io.WriteString(out, "<table>\n<thead>\n")
+	r.RenderBlock(rows[0], out)
+	io.WriteString(out, "</thead>\n")
+	if len(rows) > 1 {
+		io.WriteString(out, "<tbody>\n")
+		for _, row := range rows[1:] {
+			r.RenderBlock(row, out)
+		}
+		io.WriteString(out, "</tbody>\n")
+	}
+	io.WriteString(out, "</table>\n")
+}
+
+func renderTableRow(r *HTMLRenderer, b Block, out io.Writer) {
+	io.WriteString(out, "<tr>\n")
+	r.RenderChildren(b, out)
+	io.WriteString(out, "</tr>\n")
+}
+
+var tableAlignmentNames = map[TableAlignment]string{
+	AlignLeft:   "left",
+	AlignCenter: "center",
+	AlignRight:  "right",
+}
+
+func renderTableCell(r *HTMLRenderer, b Block, out io.Writer) {
+	cell := b.(*TableCell)
+	tag := "td"
+	if cell.Header {
+		tag = "th"
+	}
+	io.WriteString(out, "<"+tag)
+	if name, ok := tableAlignmentNames[cell.Alignment]; ok {
+		r.WriteAttr("align", []byte(name), out)
+	}
+	io.WriteString(out, ">")
+	r.RenderInline(cell.InlineContent, out)
+	io.WriteString(out, "</"+tag+">\n")
+}
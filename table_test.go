@@ -0,0 +1,33 @@
+package commonmark
+
+import (
+	"testing"
+)
+
+func TestTables(t *testing.T) {
+	// Examples mostly taken from the GFM spec.
+	runHTMLTests(t, New(WithExtensions(Tables)), []htmlTest{
+		{"| foo | bar |\n| --- | --- |\n| baz | bim |\n",
+			"<table>\n<thead>\n<tr>\n<th>foo</th>\n<th>bar</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>baz</td>\n<td>bim</td>\n</tr>\n</tbody>\n</table>\n"},
+		{"| abc | defghi |\n:-: | -----------:\nbar | baz\n",
+			"<table>\n<thead>\n<tr>\n<th align=\"center\">abc</th>\n<th align=\"right\">defghi</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td align=\"center\">bar</td>\n<td align=\"right\">baz</td>\n</tr>\n</tbody>\n</table>\n"},
+		{"| f\\|oo  |\n| :----- |\n| b `\\|` az |\n",
+			"<table>\n<thead>\n<tr>\n<th align=\"left\">f|oo</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td align=\"left\">b <code>|</code> az</td>\n</tr>\n</tbody>\n</table>\n"},
+		{"| abc | def |\n| --- | --- |\n| bar | baz |\n> bar\n",
+			"<table>\n<thead>\n<tr>\n<th>abc</th>\n<th>def</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>bar</td>\n<td>baz</td>\n</tr>\n</tbody>\n</table>\n<blockquote>\n<p>bar</p>\n</blockquote>\n"},
+		{"| abc | def |\n| --- | --- |\n| bar | baz |\nbar\n\nbar\n",
+			"<table>\n<thead>\n<tr>\n<th>abc</th>\n<th>def</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>bar</td>\n<td>baz</td>\n</tr>\n<tr>\n<td>bar</td>\n<td></td>\n</tr>\n</tbody>\n</table>\n<p>bar</p>\n"},
+		{"| abc | def |\n| --- |\n| bar |\n",
+			"<p>| abc | def |\n| --- |\n| bar |</p>\n"},
+		{"| abc | def |\n| --- | --- |\n| bar |\n| bar | baz | boo |\n",
+			"<table>\n<thead>\n<tr>\n<th>abc</th>\n<th>def</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>bar</td>\n<td></td>\n</tr>\n<tr>\n<td>bar</td>\n<td>baz</td>\n</tr>\n</tbody>\n</table>\n"},
+		{"| abc | def |\n| --- | --- |\n",
+			"<table>\n<thead>\n<tr>\n<th>abc</th>\n<th>def</th>\n</tr>\n</thead>\n</table>\n"},
+		{"intro\ntext\na | b\n--|--\n",
+			"<p>intro\ntext</p>\n<table>\n<thead>\n<tr>\n<th>a</th>\n<th>b</th>\n</tr>\n</thead>\n</table>\n"},
+		{"> a | b\n> --|--\n> c | d\n",
+			"<blockquote>\n<table>\n<thead>\n<tr>\n<th>a</th>\n<th>b</th>\n</tr>\n</thead>\n<tbody>\n<tr>\n<td>c</td>\n<td>d</td>\n</tr>\n</tbody>\n</table>\n</blockquote>\n"},
+		{"Foo\n---\n",
+			"<h2>Foo</h2>\n"},
+	})
+}
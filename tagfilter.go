@@ -0,0 +1,32 @@
+package commonmark
+
+import (
+	"regexp"
+)
+
+// "GFM enables the tagfilter extension, where the following HTML tags will be
+// filtered when rendering HTML output: <title> <textarea> <style> <xmp>
+// <iframe> <noembed> <noframes> <script> <plaintext>"
+//
+// "Filtering is done by replacing the leading < with the entity &lt;. These
+// tags are chosen in particular as they change how HTML is interpreted in a
+// way unique to them (i.e. nested HTML is interpreted differently), and this
+// is usually undesireable in the context of other rendered Markdown content."
+var disallowedTagRe = regexp.MustCompile(`(?i)</?(?:title|textarea|style|xmp|iframe|noembed|noframes|script|plaintext)(?:[\t\n\f\r />]|$)`)
+
+// filterDisallowedTags returns the raw HTML with the leading < of every
+// disallowed tag, opening or closing, replaced by &lt;.
+func filterDisallowedTags(data []byte) []byte {
+	matches := disallowedTagRe.FindAllIndex(data, -1)
+	if matches == nil {
+		return data
+	}
+	var filtered []byte
+	var start int
+	for _, m := range matches {
+		filtered = append(filtered, data[start:m[0]]...)
+		filtered = append(filtered, "&lt;"...)
+		start = m[0] + 1
+	}
+	return append(filtered, data[start:]...)
+}
@@ -0,0 +1,21 @@
+package commonmark
+
+import (
+	"testing"
+)
+
+func TestTagFilter(t *testing.T) {
+	runHTMLTests(t, New(WithTagFilter()), []htmlTest{
+		// From the GFM spec.
+		{"<strong> <title> <style> <em>\n\n<blockquote>\n  <xmp> is disallowed.  <XMP> is also disallowed.\n</blockquote>\n",
+			"<p><strong> &lt;title> &lt;style> <em></p>\n<blockquote>\n  &lt;xmp> is disallowed.  &lt;XMP> is also disallowed.\n</blockquote>\n"},
+		{"<script src=\"x.js\"></script>\n", "&lt;script src=\"x.js\">&lt;/script>\n"},
+		{"a <iframe/> <titles> <textarea\nrows=2>\n", "<p>a &lt;iframe/> <titles> &lt;textarea\nrows=2></p>\n"},
+		{"`<script>`\n", "<p><code>&lt;script&gt;</code></p>\n"},
+	})
+	policy := NewHTMLPolicy()
+	policy.AllowTags("div")
+	runHTMLTests(t, New(WithTagFilter(), WithHTMLPolicy(policy)), []htmlTest{
+		{"<div><style>p{}</style></div>\n", "<div>&lt;style>p{}&lt;/style></div>\n"},
+	})
+}
@@ -0,0 +1,96 @@
+package commonmark
+
+import (
+	"io"
+	"regexp"
+)
+
+// TaskLists is an Extension that adds support for task list items, as
+// specified by GitHub Flavored Markdown:
+// https://github.github.com/gfm/#task-list-items-extension-
+//
+// "A task list item marker consists of optional spaces, a left bracket ([),
+// either a whitespace character or the letter x in either lowercase or
+// uppercase, and then a right bracket (])."
+//
+// A list item whose first paragraph starts with a task list item marker,
+// followed by whitespace, gets its Task and Checked fields set, and the marker
+// is replaced by a TaskCheckbox inline.
+var TaskLists Extension = taskListsExtension{}
+
+type taskListsExtension struct{}
+
+func (taskListsExtension) Extend(c *Converter) {
+	c.AddTransform(AfterInlineParsing, findTaskListItems, 0)
+	c.Renderer().SetInlineFunc(&TaskCheckbox{}, renderTaskCheckbox)
+}
+
+// TaskCheckbox is the checkbox at the start of a task list item.
+type TaskCheckbox struct {
+	Checked bool
+}
+
+var taskListItemMarkerRe = regexp.MustCompile(`^\[([ xX])\][ \n]`)
+
+// findTaskListItems is a Transform that marks the task list items in the
+// tree, and replaces their markers by TaskCheckboxes.
+func findTaskListItems(doc *Document) error {
+	walkBlocks(doc, func(b Block) {
+		if item, isListItem := b.(*ListItem); isListItem {
+			markTaskListItem(item)
+		}
+	})
+	return nil
+}
+
+func markTaskListItem(item *ListItem) {
+	children := item.Children()
+	if len(children) == 0 {
+		return
+	}
+	par, isParagraph := children[0].(*Paragraph)
+	if !isParagraph {
+		return
+	}
+	m := taskListItemMarkerRe.FindSubmatch(par.Content)
+	if m == nil {
+		return
+	}
+	// The marker is at the start of the inline content, but it can be split
+	// over several strings, because the '[' might have opened a link.
+	inlines, ok := par.InlineContent.(*MultipleInline)
+	if !ok {
+		return
+	}
+	rest := inlines.Children
+	length := len(m[0]) - 1
+	for length > 0 {
+		if len(rest) == 0 {
+			return
+		}
+		str, ok := rest[0].(*StringInline)
+		if !ok {
+			return
+		}
+		if len(str.Content) > length {
+			rest = append([]Inline{&StringInline{str.Content[length:]}}, rest[1:]...)
+			break
+		}
+		length -= len(str.Content)
+		rest = rest[1:]
+	}
+
+	item.Task = true
+	item.Checked = m[1][0] != ' '
+	inlines.Children = append([]Inline{&TaskCheckbox{Checked: item.Checked}}, rest...)
+}
+
+func renderTaskCheckbox(r *HTMLRenderer, i Inline, out io.Writer) {
+	io.WriteString(out, "<input")
+	r.WriteAttr("type", []byte("checkbox"), out)
+	if i.(*TaskCheckbox).Checked {
+		r.WriteBoolAttr("checked", out)
+	}
+	r.WriteBoolAttr("disabled", out)
+	r.EndVoidTag(out)
+}
@@ -0,0 +1,26 @@
+package commonmark
+
+import (
+	"testing"
+)
+
+func TestTaskLists(t *testing.T) {
+	runHTMLTests(t, New(WithExtensions(TaskLists)), []htmlTest{
+		{"- [ ] foo\n- [x] bar\n",
+			"<ul>\n<li><input type=\"checkbox\" disabled=\"disabled\" /> foo</li>\n<li><input type=\"checkbox\" checked=\"checked\" disabled=\"disabled\" /> bar</li>\n</ul>\n"},
+		{"- [x] foo\n  - [ ] bar\n  - [X] baz\n- [ ] bim\n",
+			"<ul>\n<li><input type=\"checkbox\" checked=\"checked\" disabled=\"disabled\" /> foo\n<ul>\n<li><input type=\"checkbox\" disabled=\"disabled\" /> bar</li>\n<li><input type=\"checkbox\" checked=\"checked\" disabled=\"disabled\" /> baz</li>\n</ul></li>\n<li><input type=\"checkbox\" disabled=\"disabled\" /> bim</li>\n</ul>\n"},
+		{"1. [ ] foo\n\n2. [x] bar\n",
+			"<ol>\n<li><p><input type=\"checkbox\" disabled=\"disabled\" /> foo</p></li>\n<li><p><input type=\"checkbox\" checked=\"checked\" disabled=\"disabled\" /> bar</p></li>\n</ol>\n"},
+		{"- [x]\n  foo\n",
+			"<ul>\n<li><input type=\"checkbox\" checked=\"checked\" disabled=\"disabled\" />\nfoo</li>\n</ul>\n"},
+		{"- [y] foo\n- [x]foo\n- `[x]` foo\n- > [x] foo\n",
+			"<ul>\n<li>[y] foo</li>\n<li>[x]foo</li>\n<li><code>[x]</code> foo</li>\n<li><blockquote>\n<p>[x] foo</p>\n</blockquote></li>\n</ul>\n"},
+		{"[ ] foo\n",
+			"<p>[ ] foo</p>\n"},
+	})
+	runHTMLTests(t, New(WithExtensions(TaskLists), WithHTMLFlavor(HTML5)), []htmlTest{
+		{"- [x] foo\n",
+			"<ul>\n<li><input type=\"checkbox\" checked disabled> foo</li>\n</ul>\n"},
+	})
+}
@@ -0,0 +1,130 @@
+package commonmark
+
+import (
+	"io"
+)
+
+// Superscript is an Extension that renders text between single carets, like
+// 2^10^, as superscript.
+var Superscript Extension = &textStyleExtension{
+	char:    '^',
+	length:  1,
+	example: &SuperscriptInline{},
+	wrap:    func(children []Inline) Inline { return &SuperscriptInline{children} },
+	render:  renderSuperscriptInline,
+}
+
+// Subscript is an Extension that renders text between single tildes, like
+// H~2~O, as subscript. To use it together with strikethrough, use
+// DoubleTildeStrikethrough rather than Strikethrough, which also claims
+// single tildes.
+var Subscript Extension = &textStyleExtension{
+	char:    '~',
+	length:  1,
+	example: &SubscriptInline{},
+	wrap:    func(children []Inline) Inline { return &SubscriptInline{children} },
+	render:  renderSubscriptInline,
+}
+
+// Highlight is an Extension that renders text between pairs of equals signs,
+// like ==this==, as highlighted (marked) text.
+var Highlight Extension = &textStyleExtension{
+	char:    '=',
+	length:  2,
+	example: &HighlightInline{},
+	wrap:    func(children []Inline) Inline { return &HighlightInline{children} },
+	render:  renderHighlightInline,
+}
+
+// Inserted is an Extension that renders text between pairs of plus signs,
+// like ++this++, as inserted text.
+var Inserted Extension = &textStyleExtension{
+	char:    '+',
+	length:  2,
+	example: &InsertedInline{},
+	wrap:    func(children []Inline) Inline { return &InsertedInline{children} },
+	render:  renderInsertedInline,
+}
+
+// textStyleExtension adds a DelimiterRule for runs of length characters, and
+// a render function for the inlines, of the same type as example, that wrap
+// returns for them. It is used through a pointer, because it is not
+// comparable.
+type textStyleExtension struct {
+	char    byte
+	length  int
+	example Inline
+	wrap    func(children []Inline) Inline
+	render  InlineRenderFunc
+}
+
+func (e *textStyleExtension) Extend(c *Converter) {
+	c.AddDelimiterRule(DelimiterRule{
+		Char:      e.char,
+		MinLength: e.length,
+		MaxLength: e.length,
+		Wrap:      e.wrap,
+	})
+	c.Renderer().SetInlineFunc(e.example, e.render)
+}
+
+// SuperscriptInline is superscript text.
+type SuperscriptInline struct {
+	Children []Inline
+}
+
+func (s *SuperscriptInline) InlineChildren() []Inline {
+	return s.Children
+}
+
+// SubscriptInline is subscript text.
+type SubscriptInline struct {
+	Children []Inline
+}
+
+func (s *SubscriptInline) InlineChildren() []Inline {
+	return s.Children
+}
+
+// HighlightInline is highlighted text.
+type HighlightInline struct {
+	Children []Inline
+}
+
+func (h *HighlightInline) InlineChildren() []Inline {
+	return h.Children
+}
+
+// InsertedInline is text that has been inserted.
+type InsertedInline struct {
+	Children []Inline
+}
+
+func (i *InsertedInline) InlineChildren() []Inline {
+	return i.Children
+}
+
+func renderSuperscriptInline(r *HTMLRenderer, i Inline, out io.Writer) {
+	renderTagged(r, "sup", i.(*SuperscriptInline).Children, out)
+}
+
+func renderSubscriptInline(r *HTMLRenderer, i Inline, out io.Writer) {
+	renderTagged(r, "sub", i.(*SubscriptInline).Children, out)
+}
+
+func renderHighlightInline(r *HTMLRenderer, i Inline, out io.Writer) {
+	renderTagged(r, "mark", i.(*HighlightInline).Children, out)
+}
+
+func renderInsertedInline(r *HTMLRenderer, i Inline, out io.Writer) {
+	renderTagged(r, "ins", i.(*InsertedInline).Children, out)
+}
+
+// renderTagged renders the inlines wrapped in an element with the given tag.
+func renderTagged(r *HTMLRenderer, tag string, children []Inline, out io.Writer) {
+	io.WriteString(out, "<"+tag+">")
+	for _, child := range children {
+		r.RenderInline(child, out)
+	}
+	io.WriteString(out, "</"+tag+">")
+}
@@ -0,0 +1,92 @@
+package commonmark
+
+import (
+	"io"
+	"testing"
+)
+
+func TestTextStyles(t *testing.T) {
+	runHTMLTests(t, New(WithExtensions(Superscript, Subscript, Highlight, Inserted, DoubleTildeStrikethrough)), []htmlTest{
+		{"2^10^ and H~2~O\n", "<p>2<sup>10</sup> and H<sub>2</sub>O</p>\n"},
+		{"==marked== and ++new++ and ~~old~~\n", "<p><mark>marked</mark> and <ins>new</ins> and <del>old</del></p>\n"},
+		{"a ^ b ^ c, x = y == z, 1 + 2 ++ 3\n", "<p>a ^ b ^ c, x = y == z, 1 + 2 ++ 3</p>\n"},
+		{"=a= +a+ ^^a^^ ~~~a~~~\n", "<p>=a= +a+ ^^a^^ ~~~a~~~</p>\n"},
+		{"==a ^b^ ~c~==\n", "<p><mark>a <sup>b</sup> <sub>c</sub></mark></p>\n"},
+		{"~~a ~b~ c~~\n", "<p><del>a <sub>b</sub> c</del></p>\n"},
+		{"^a `b^` c^\n", "<p><sup>a <code>b^</code> c</sup></p>\n"},
+		{"\\^a^\n", "<p>^a^</p>\n"},
+		{"[^a^](/u)^\n", "<p><a href=\"/u\"><sup>a</sup></a>^</p>\n"},
+		{"++a\n\nb++\n", "<p>++a</p>\n<p>b++</p>\n"},
+	})
+	runHTMLTests(t, New(), []htmlTest{
+		{"2^10^ H~2~O ==a== ++b++\n", "<p>2^10^ H~2~O ==a== ++b++</p>\n"},
+	})
+}
+
+type spoilerInline struct {
+	Children []Inline
+}
+
+func (s *spoilerInline) InlineChildren() []Inline {
+	return s.Children
+}
+
+func TestDelimiterRules(t *testing.T) {
+	converter := New(
+		WithDelimiterRule(DelimiterRule{
+			Char:      '|',
+			MinLength: 2,
+			MaxLength: 2,
+			Wrap: func(children []Inline) Inline {
+				return &spoilerInline{children}
+			},
+		}),
+		WithInlineRenderFunc(&spoilerInline{}, func(r *HTMLRenderer, i Inline, out io.Writer) {
+			io.WriteString(out, "<span class=\"spoiler\">")
+			for _, child := range i.(*spoilerInline).Children {
+				r.RenderInline(child, out)
+			}
+			io.WriteString(out, "</span>")
+		}),
+		WithExtensions(Strikethrough, Subscript),
+		WithHeaderIDs(nil),
+		WithTOCMarker(1, 6),
+	)
+	runHTMLTests(t, converter, []htmlTest{
+		{"||secret|| and a || b | c\n", "<p><span class=\"spoiler\">secret</span> and a || b | c</p>\n"},
+		// Strikethrough was added first, so it takes single tildes.
+		{"~a~ ~~b~~\n", "<p><del>a</del> <del>b</del></p>\n"},
+		// The text of custom inlines counts for header IDs and the TOC.
+		{"[TOC]\n\n# ||x|| ~y~\n",
+			"<ul>\n<li><a href=\"#x-y\">x y</a></li>\n</ul>\n<h1 id=\"x-y\"><span class=\"spoiler\">x</span> <del>y</del></h1>\n"},
+	})
+	runHTMLTests(t, New(WithExtensions(Superscript, Subscript, Highlight, Inserted), WithHeaderIDs(nil)), []htmlTest{
+		{"# a^2^ H~2~O ==b== ++c++\n", "<h1 id=\"a2-h2o-b-c\">a<sup>2</sup> H<sub>2</sub>O <mark>b</mark> <ins>c</ins></h1>\n"},
+	})
+}
+
+func TestInvalidDelimiterRules(t *testing.T) {
+	wrap := func(children []Inline) Inline { return &MultipleInline{children} }
+	rules := []DelimiterRule{
+		{Char: '[', MinLength: 1, MaxLength: 1, Wrap: wrap},
+		{Char: '\\', MinLength: 1, MaxLength: 1, Wrap: wrap},
+		{Char: '"', MinLength: 1, MaxLength: 1, Wrap: wrap},
+		{Char: '\'', MinLength: 2, MaxLength: 2, Wrap: wrap},
+		{Char: '`', MinLength: 1, MaxLength: 1, Wrap: wrap},
+		{Char: '-', MinLength: 2, MaxLength: 2, Wrap: wrap},
+		{Char: '.', MinLength: 1, MaxLength: 1, Wrap: wrap},
+		{Char: '|', MinLength: 0, MaxLength: 2, Wrap: wrap},
+		{Char: '|', MinLength: 2, MaxLength: 1, Wrap: wrap},
+		{Char: '|', MinLength: 1, MaxLength: 1},
+	}
+	for _, rule := range rules {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected AddDelimiterRule to reject %+v", rule)
+				}
+			}()
+			New(WithDelimiterRule(rule))
+		}()
+	}
+}
@@ -0,0 +1,129 @@
+package commonmark
+
+import (
+	"bytes"
+	"io"
+)
+
+// TOCEntry is an entry in a table of contents, which corresponds to a
+// header.
+type TOCEntry struct {
+	// Level is the level of the header, 1-6.
+	Level int
+	// Text is the plain text of the header; see PlainText.
+	Text string
+	// ID is the ID of the header, which is empty if it has none; see
+	// WithHeaderIDs.
+	ID string
+	// Children are the entries for the headers that follow this one, up to
+	// the next header of the same or a lower level.
+	Children []*TOCEntry
+}
+
+// BuildTOC returns a table of contents for the headers in the document, from
+// level minLevel through maxLevel inclusive. The entries are nested according
+// to their levels; if a level is skipped, the entry is simply nested one level
+// deeper, without any empty entries in between.
+//
+// BuildTOC needs the inline content of the headers, so it must be called on a
+// document returned by Converter.Parse, or from an AfterInlineParsing
+// Transform.
+func BuildTOC(doc *Document, minLevel, maxLevel int) []*TOCEntry {
+	var roots []*TOCEntry
+	// open holds the entries that can still get children, from the outside
+	// in.
+	var open []*TOCEntry
+	walkBlocks(doc, func(b Block) {
+		h, ok := b.(*Header)
+		if !ok || h.Level < minLevel || h.Level > maxLevel {
+			return
+		}
+		entry := &TOCEntry{Level: h.Level, Text: string(PlainText(h.InlineContent)), ID: h.ID}
+		for len(open) > 0 && open[len(open)-1].Level >= h.Level {
+			open = open[:len(open)-1]
+		}
+		if len(open) == 0 {
+			roots = append(roots, entry)
+		} else {
+			parent := open[len(open)-1]
+			parent.Children = append(parent.Children, entry)
+		}
+		open = append(open, entry)
+	})
+	return roots
+}
+
+// TOC is a table of contents, which replaces a [TOC] marker; see
+// WithTOCMarker.
+type TOC struct {
+	BaseBlock
+	Entries []*TOCEntry
+}
+
+// WithTOCMarker makes the Converter replace every paragraph that consists of
+// just the marker [TOC] by a table of contents of the headers from level
+// minLevel through maxLevel, rendered as a nested list of links. Headers only
+// get links if they have IDs, so this is typically combined with
+// WithHeaderIDs.
+//
+// The table of contents is built by a Transform with priority 100, which runs
+// after the one that assigns header IDs.
+func WithTOCMarker(minLevel, maxLevel int) Option {
+	return func(c *Converter) {
+		c.AddTransform(AfterInlineParsing, func(doc *Document) error {
+			replaceTOCMarkers(doc, minLevel, maxLevel)
+			return nil
+		}, 100)
+		c.renderer.SetBlockFunc(&TOC{}, renderTOC)
+	}
+}
+
+var tocMarker = []byte("[TOC]")
+
+// replaceTOCMarkers replaces every [TOC] paragraph in the document by a TOC.
+func replaceTOCMarkers(doc *Document, minLevel, maxLevel int) {
+	var entries []*TOCEntry
+	walkBlocks(doc, func(b Block) {
+		children := append([]Block(nil), b.Children()...)
+		for i, child := range children {
+			if par, ok := child.(*Paragraph); ok && bytes.Equal(bytes.TrimSpace(par.Content), tocMarker) {
+				if entries == nil {
+					entries = BuildTOC(doc, minLevel, maxLevel)
+				}
+				children[i] = &TOC{Entries: entries}
+			}
+		}
+		b.SetChildren(children)
+	})
+}
+
+func renderTOC(r *HTMLRenderer, b Block, out io.Writer) {
+	r.RenderTOC(b.(*TOC).Entries, out)
+}
+
+// RenderTOC writes a table of contents as a nested <ul> list. Entries with an
+// ID link to it. Nothing is written if there are no entries.
+func (r *HTMLRenderer) RenderTOC(entries []*TOCEntry, out io.Writer) {
+	if len(entries) == 0 {
+		return
+	}
+	io.WriteString(out, "<ul>\n")
+	for _, entry := range entries {
+		io.WriteString(out, "<li>")
+		if entry.ID != "" {
+			io.WriteString(out, "<a")
+			r.WriteAttr("href", []byte("#"+entry.ID), out)
+			io.WriteString(out, ">")
+			WriteEscaped([]byte(entry.Text), out)
+			io.WriteString(out, "</a>")
+		} else {
+			WriteEscaped([]byte(entry.Text), out)
+		}
+		if len(entry.Children) > 0 {
+			io.WriteString(out, "\n")
+			r.RenderTOC(entry.Children, out)
+		}
+		io.WriteString(out, "</li>\n")
+	}
+	io.WriteString(out, "</ul>\n")
+}
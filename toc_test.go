@@ -0,0 +1,66 @@
+package commonmark
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildTOC(t *testing.T) {
+	doc, err := New(WithHeaderIDs(nil)).Parse([]byte("# Title\n## Intro\n#### Deep\n### Details `x`\n## Usage\n###### Tiny\n# Appendix\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		minLevel, maxLevel int
+		expected           []*TOCEntry
+	}{
+		{1, 6, []*TOCEntry{
+			{Level: 1, Text: "Title", ID: "title", Children: []*TOCEntry{
+				{Level: 2, Text: "Intro", ID: "intro", Children: []*TOCEntry{
+					{Level: 4, Text: "Deep", ID: "deep"},
+					{Level: 3, Text: "Details x", ID: "details-x"},
+				}},
+				{Level: 2, Text: "Usage", ID: "usage", Children: []*TOCEntry{
+					{Level: 6, Text: "Tiny", ID: "tiny"},
+				}},
+			}},
+			{Level: 1, Text: "Appendix", ID: "appendix"},
+		}},
+		{2, 3, []*TOCEntry{
+			{Level: 2, Text: "Intro", ID: "intro", Children: []*TOCEntry{
+				{Level: 3, Text: "Details x", ID: "details-x"},
+			}},
+			{Level: 2, Text: "Usage", ID: "usage"},
+		}},
+		{5, 5, nil},
+	}
+	for _, test := range tests {
+		toc := BuildTOC(doc, test.minLevel, test.maxLevel)
+		if !reflect.DeepEqual(toc, test.expected) {
+			t.Errorf("incorrect TOC for levels %d-%d", test.minLevel, test.maxLevel)
+		}
+	}
+}
+
+func TestTOCMarker(t *testing.T) {
+	runHTMLTests(t, New(WithTOCMarker(1, 2), WithHeaderIDs(nil)), []htmlTest{
+		{"[TOC]\n\n# A\n## B\n### C\n## D\n",
+			"<ul>\n<li><a href=\"#a\">A</a>\n<ul>\n<li><a href=\"#b\">B</a></li>\n<li><a href=\"#d\">D</a></li>\n</ul>\n</li>\n</ul>\n" +
+				"<h1 id=\"a\">A</h1>\n<h2 id=\"b\">B</h2>\n<h3 id=\"c\">C</h3>\n<h2 id=\"d\">D</h2>\n"},
+		{"> [TOC]\n\nnot [TOC]\n\n## <b>&amp;</b>\n",
+			"<blockquote>\n<ul>\n<li>&amp;</li>\n</ul>\n</blockquote>\n<p>not [TOC]</p>\n<h2><b>&amp;</b></h2>\n"},
+	})
+	runHTMLTests(t, New(WithTOCMarker(1, 6)), []htmlTest{
+		{"# A & B\n[TOC]\n", "<h1>A &amp; B</h1>\n<ul>\n<li>A &amp; B</li>\n</ul>\n"},
+		{"[TOC]\n", ""},
+	})
+	converter := New(
+		WithTOCMarker(1, 6),
+		WithBlockParsers(boxParser{}),
+		WithBlockRenderFunc(&box{}, renderBox),
+	)
+	runHTMLTests(t, converter, []htmlTest{
+		{"; # A\n\n[TOC]\n", "<div>\n<h1>A</h1>\n</div>\n<ul>\n<li>A</li>\n</ul>\n"},
+		{"; [TOC]\n\n# A\n", "<div>\n<ul>\n<li>A</li>\n</ul>\n</div>\n<h1>A</h1>\n"},
+	})
+}
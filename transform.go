@@ -0,0 +1,39 @@
+package commonmark
+
+// Transform modifies the parse tree of a document between parsing phases,
+// for example to assign IDs to headers, to rewrite link destinations, or to
+// restructure the tree. If it returns an error, the conversion fails with
+// that error.
+type Transform func(doc *Document) error
+
+// TransformPhase identifies the point in the conversion at which a Transform
+// is run.
+type TransformPhase int
+
+const (
+	// AfterBlockParsing is right after the block structure has been parsed.
+	// Blocks have their raw Content, but no InlineContent yet.
+	AfterBlockParsing TransformPhase = iota
+	// AfterInlineParsing is right after inline content has been parsed,
+	// before the tree is rendered.
+	AfterInlineParsing
+)
+
+// runTransforms runs the given transforms in order, stopping at the first
+// error.
+func runTransforms(doc *Document, transforms []prioritizedTransform) error {
+	for _, t := range transforms {
+		if err := t.transform(doc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkBlocks calls f for b and all of its descendants, in document order.
+func walkBlocks(b Block, f func(Block)) {
+	f(b)
+	for _, child := range b.Children() {
+		walkBlocks(child, f)
+	}
+}
@@ -0,0 +1,48 @@
+package commonmark
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestTransforms(t *testing.T) {
+	var phases []string
+	converter := New(
+		WithTransform(AfterInlineParsing, func(doc *Document) error {
+			phases = append(phases, "inlines")
+			walkBlocks(doc, func(b Block) {
+				if p, ok := b.(*Paragraph); ok {
+					p.InlineContent = &MultipleInline{[]Inline{&StringInline{[]byte("[")}, p.InlineContent, &StringInline{[]byte("]")}}}
+				}
+			})
+			return nil
+		}),
+		WithTransform(AfterBlockParsing, func(doc *Document) error {
+			phases = append(phases, "blocks")
+			walkBlocks(doc, func(b Block) {
+				if h, ok := b.(*Header); ok {
+					if h.InlineContent != nil {
+						t.Errorf("inline content already parsed before AfterBlockParsing transform")
+					}
+					h.Content = bytes.ToUpper(h.Content)
+				}
+			})
+			return nil
+		}),
+	)
+	runHTMLTests(t, converter, []htmlTest{
+		{"# a `b`\n\nc\n", "<h1>A <code>B</code></h1>\n<p>[c]</p>\n"},
+	})
+	if len(phases) != 2 || phases[0] != "blocks" || phases[1] != "inlines" {
+		t.Errorf("transforms ran in incorrect order: %v", phases)
+	}
+
+	transformErr := errors.New("transform failed")
+	converter = New(WithTransform(AfterBlockParsing, func(doc *Document) error {
+		return transformErr
+	}))
+	if _, err := converter.ToHTMLBytes([]byte("a\n")); err != transformErr {
+		t.Errorf("expected transform error, got %v", err)
+	}
+}
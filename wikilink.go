@@ -0,0 +1,118 @@
+package commonmark
+
+import (
+	"bytes"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// WikiLinkResolver returns the URL of the wiki page with the given name, and
+// whether the page exists.
+//
+// The page name comes straight from the input, so if that is untrusted, the
+// resolver must make sure the URL it returns is safe: the renderer writes it
+// into the href attribute as is (apart from escaping), and a page called
+// javascript:alert(1) must not turn into a link that runs a script.
+type WikiLinkResolver func(page string) (url string, exists bool)
+
+// WikiLinks returns an Extension that adds support for wiki links: [[Page
+// Name]] links to the page called Page Name, and [[Page Name|label]] does so
+// with a different link text. The resolver is called for every wiki link; if
+// it is nil, every page exists, and its URL is its name as a relative path
+// (prefixed by "./" if the name would otherwise be read as a URL scheme, as
+// in [[javascript:alert(1)]]).
+//
+// Wiki links are rendered as <a class="wikilink">, and links to pages that
+// don't exist get the additional class wikilink-missing.
+func WikiLinks(resolver WikiLinkResolver) Extension {
+	if resolver == nil {
+		resolver = defaultWikiLinkResolver
+	}
+	return &wikiLinksExtension{resolver}
+}
+
+func defaultWikiLinkResolver(page string) (string, bool) {
+	// Per RFC 3986, a colon in the first path segment of a relative
+	// reference makes it look like a scheme; "./" disambiguates.
+	if i := strings.IndexAny(page, ":/?#"); i >= 0 && page[i] == ':' {
+		return "./" + page, true
+	}
+	return page, true
+}
+
+// wikiLinksExtension is used through a pointer, because it is not comparable.
+type wikiLinksExtension struct {
+	resolver WikiLinkResolver
+}
+
+func (e *wikiLinksExtension) Extend(c *Converter) {
+	c.AddInlineParser('[', wikiLinkParser{e.resolver}, 0)
+	c.Renderer().SetInlineFunc(&WikiLink{}, renderWikiLink)
+}
+
+// WikiLink is a link to a wiki page.
+type WikiLink struct {
+	// Page is the name of the page.
+	Page string
+	// Destination is the URL of the page, as returned by the
+	// WikiLinkResolver.
+	Destination []byte
+	// Exists is false if the page does not exist.
+	Exists bool
+	// Children hold the link text: the label, or else the page name.
+	Children []Inline
+}
+
+// InlineChildren implements ParentInline.
+func (w *WikiLink) InlineChildren() []Inline {
+	return w.Children
+}
+
+var wikiLinkRe = regexp.MustCompile(`^\[\[([^\[\]|\n]+)(?:\|([^\[\]\n]+))?\]\]`)
+
+type wikiLinkParser struct {
+	resolver WikiLinkResolver
+}
+
+func (p wikiLinkParser) Parse(s InlineParserState) (Inline, int) {
+	// Links may not contain other links.
+	if s.InLinkText() {
+		return nil, 0
+	}
+	m := wikiLinkRe.FindSubmatch(s.Data()[s.Pos():])
+	if m == nil {
+		return nil, 0
+	}
+	page := unescapeString(bytes.TrimSpace(m[1]))
+	if len(page) == 0 {
+		return nil, 0
+	}
+	label := page
+	if m[2] != nil {
+		label = unescapeString(bytes.TrimSpace(m[2]))
+	}
+	url, exists := p.resolver(string(page))
+	return &WikiLink{
+		Page:        string(page),
+		Destination: []byte(url),
+		Exists:      exists,
+		Children:    []Inline{&StringInline{label}},
+	}, len(m[0])
+}
+
+func renderWikiLink(r *HTMLRenderer, i Inline, out io.Writer) {
+	link := i.(*WikiLink)
+	io.WriteString(out, "<a")
+	r.WriteAttr("href", EscapeURL(link.Destination), out)
+	if link.Exists {
+		r.WriteAttr("class", []byte("wikilink"), out)
+	} else {
+		r.WriteAttr("class", []byte("wikilink wikilink-missing"), out)
+	}
+	io.WriteString(out, ">")
+	for _, child := range link.Children {
+		r.RenderInline(child, out)
+	}
+	io.WriteString(out, "</a>")
+}
@@ -0,0 +1,32 @@
+package commonmark
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWikiLinks(t *testing.T) {
+	pages := map[string]bool{"Home": true, "Getting Started": true}
+	resolver := func(page string) (string, bool) {
+		return "/wiki/" + strings.Replace(page, " ", "_", -1), pages[page]
+	}
+	runHTMLTests(t, New(WithExtensions(WikiLinks(resolver))), []htmlTest{
+		{"See [[Home]].\n", "<p>See <a href=\"/wiki/Home\" class=\"wikilink\">Home</a>.</p>\n"},
+		{"[[ Getting Started | the guide ]]\n", "<p><a href=\"/wiki/Getting_Started\" class=\"wikilink\">the guide</a></p>\n"},
+		{"[[Runbooks/Database]]\n", "<p><a href=\"/wiki/Runbooks/Database\" class=\"wikilink wikilink-missing\">Runbooks/Database</a></p>\n"},
+		{"[[Home|<b> & \\*]]\n", "<p><a href=\"/wiki/Home\" class=\"wikilink\">&lt;b&gt; &amp; *</a></p>\n"},
+		{"[[]] [[ ]] [[a\nb]] [[a]b]] [[Home]\n", "<p>[[]] [[ ]] [[a\nb]] [[a]b]] [[Home]</p>\n"},
+		{"`[[Home]]` [x](/y)\n", "<p><code>[[Home]]</code> <a href=\"/y\">x</a></p>\n"},
+		{"[[Home\\_Page]]\n", "<p><a href=\"/wiki/Home_Page\" class=\"wikilink wikilink-missing\">Home_Page</a></p>\n"},
+		{"[see [[Home]]](/u)\n", "<p><a href=\"/u\">see [[Home]]</a></p>\n"},
+		{"a [ b [[Home]]\n", "<p>a [ b <a href=\"/wiki/Home\" class=\"wikilink\">Home</a></p>\n"},
+	})
+	runHTMLTests(t, New(WithExtensions(WikiLinks(nil))), []htmlTest{
+		{"[[Main Page]]\n", "<p><a href=\"Main%20Page\" class=\"wikilink\">Main Page</a></p>\n"},
+		{"[[javascript:alert(1)]]\n", "<p><a href=\"./javascript:alert(1)\" class=\"wikilink\">javascript:alert(1)</a></p>\n"},
+		{"[[Notes/2024: plans]]\n", "<p><a href=\"Notes/2024:%20plans\" class=\"wikilink\">Notes/2024: plans</a></p>\n"},
+	})
+	runHTMLTests(t, New(), []htmlTest{
+		{"[[Home]]\n", "<p>[[Home]]</p>\n"},
+	})
+}